@@ -1,5 +1,12 @@
 package Features
 
+import (
+	"fmt"
+	"os"
+
+	"github.com/goccy/go-json"
+)
+
 type Features struct {
 	IsolateRooms         bool `json:"isolateRooms"`
 	Readiness            bool `json:"readiness"`
@@ -7,6 +14,8 @@ type Features struct {
 	PersistentRooms      bool `json:"persistentRooms"`
 	Chat                 bool `json:"chat"`
 	SharedPlaylists      bool `json:"sharedPlaylists"`
+	SetOthersReadiness   bool `json:"setOthersReadiness"`
+	Speed                bool `json:"speed"` // playback speed in playstates, for clients that support it
 	MaxChatMessageLength int  `json:"maxChatMessageLength"`
 	MaxUsernameLength    int  `json:"maxUsernameLength"`
 	MaxRoomNameLength    int  `json:"maxRoomNameLength"`
@@ -14,7 +23,46 @@ type Features struct {
 }
 
 type Config struct {
-	DesyncRange float64 `json:"desyncRange"`
+	DesyncRange  float64 `json:"desyncRange"`
+	DesyncNotice float64 `json:"desyncNotice"` // seconds a user may drift before the room is told
+
+	// default room policies
+	AutoPlay          bool `json:"autoPlay"`
+	AutoPlayCountdown int  `json:"autoPlayCountdown"` // seconds
+	StrictReadiness   bool `json:"strictReadiness"`   // block unpausing until everyone is ready
+
+	ResetReadyOnIndexChange bool    `json:"resetReadyOnIndexChange"`
+	ResetReadyOnFileChange  bool    `json:"resetReadyOnFileChange"`
+	ResetReadyOnSeek        bool    `json:"resetReadyOnSeek"`
+	SeekResetThreshold      float64 `json:"seekResetThreshold"` // seconds
+
+	AutoAdvance bool   `json:"autoAdvance"`
+	Repeat      string `json:"repeat"` // off, one or all
+	Shuffle     bool   `json:"shuffle"`
+
+	// pause the room when someone leaves, until a joiner has loaded the file, or when someone
+	// falls more than PauseOnLag seconds behind (0 turns that off)
+	PauseOnLeave bool    `json:"pauseOnLeave"`
+	PauseOnJoin  bool    `json:"pauseOnJoin"`
+	PauseOnLag   float64 `json:"pauseOnLag"`
+
+	// spectators follow playback without controlling it
+	JoinAsSpectator bool `json:"joinAsSpectator"`
+	SpectatorChat   bool `json:"spectatorChat"`
+
+	// playlist URL allowlist, an empty list allows everything
+	TrustedDomains []string `json:"trustedDomains"`
+	AllowedSchemes []string `json:"allowedSchemes"`
+
+	// directory operators can import playlists from and export them to, disabled when empty
+	PlaylistDir string `json:"playlistDir"`
+
+	// chat transcript logging, disabled when ChatLogDir is empty
+	ChatLogDir        string  `json:"chatLogDir"`
+	ChatLogMaxSize    int64   `json:"chatLogMaxSize"`    // bytes
+	ChatLogMaxAge     float64 `json:"chatLogMaxAge"`     // seconds
+	ChatLogRetention  float64 `json:"chatLogRetention"`  // seconds
+	ChatLogMaxBackups int     `json:"chatLogMaxBackups"` // rotated files kept per room
 }
 
 // GlobalFeatures is a global variable that holds the features of the server
@@ -39,6 +87,8 @@ func NewFeatures() *Features {
 		PersistentRooms:      false,
 		Chat:                 true,
 		SharedPlaylists:      true,
+		SetOthersReadiness:   true,
+		Speed:                true,
 		MaxChatMessageLength: 1000,
 		MaxUsernameLength:    20,
 		MaxRoomNameLength:    20,
@@ -61,6 +111,53 @@ func SetConfig(config Config) {
 // NewConfig returns a new Config struct
 func NewConfig() *Config {
 	return &Config{
-		DesyncRange: 0.5,
+		DesyncRange:  0.5,
+		DesyncNotice: 3,
+
+		AutoPlay:          false,
+		AutoPlayCountdown: 5,
+		StrictReadiness:   false,
+
+		ResetReadyOnIndexChange: true,
+		ResetReadyOnFileChange:  true,
+		ResetReadyOnSeek:        false,
+		SeekResetThreshold:      60,
+
+		AutoAdvance: false,
+		Repeat:      "off",
+		Shuffle:     false,
+
+		PauseOnLeave: false,
+		PauseOnJoin:  false,
+		PauseOnLag:   0,
+
+		JoinAsSpectator: false,
+		SpectatorChat:   true,
+
+		TrustedDomains: []string{},
+		AllowedSchemes: []string{"http", "https"},
+
+		PlaylistDir: "",
+
+		ChatLogDir:        "",
+		ChatLogMaxSize:    10 * 1024 * 1024,
+		ChatLogMaxAge:     24 * 60 * 60,
+		ChatLogRetention:  30 * 24 * 60 * 60,
+		ChatLogMaxBackups: 30,
+	}
+}
+
+// LoadConfig reads a JSON config file, settings the file leaves out keep their defaults
+func LoadConfig(path string) (*Config, error) {
+	config := NewConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err = json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
 	}
+	return config, nil
 }
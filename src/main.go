@@ -2,12 +2,14 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"io"
 	"log"
 	"net"
 	"time"
 
 	Features "github.com/Icey-Glitch/Syncplay-G/features"
+	"github.com/Icey-Glitch/Syncplay-G/mngr/chatlog"
 	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
 
 	"github.com/goccy/go-json"
@@ -23,11 +25,44 @@ var (
 )
 
 func main() {
+	configPath := flag.String("config", "", "JSON config file, see features.Config")
+	chatLogDir := flag.String("chatlog-dir", "", "write chat transcripts to this directory (overrides chatLogDir)")
+	playlistDir := flag.String("playlist-dir", "", "import and export playlists in this directory (overrides playlistDir)")
+	flag.Parse()
+
 	features := Features.NewFeatures()
 	Features.SetGlobalFeatures(*features)
 	config := Features.NewConfig()
+	if *configPath != "" {
+		loaded, err := Features.LoadConfig(*configPath)
+		if err != nil {
+			log.Fatal("Error loading config:", err)
+		}
+		config = loaded
+	}
+	if *chatLogDir != "" {
+		config.ChatLogDir = *chatLogDir
+	}
+	if *playlistDir != "" {
+		config.PlaylistDir = *playlistDir
+	}
 	Features.SetConfig(*config)
 
+	if config.ChatLogDir != "" {
+		chatLogger, err := chatlog.NewLogger(chatlog.Options{
+			Dir:        config.ChatLogDir,
+			MaxSize:    config.ChatLogMaxSize,
+			MaxAge:     time.Duration(config.ChatLogMaxAge * float64(time.Second)),
+			Retention:  time.Duration(config.ChatLogRetention * float64(time.Second)),
+			MaxBackups: config.ChatLogMaxBackups,
+		})
+		if err != nil {
+			log.Fatal("Error starting chat logger:", err)
+		}
+		defer chatLogger.Close()
+		messages.SetChatLogger(chatLogger)
+	}
+
 	ln, err := net.Listen("tcp", ":8080")
 	if err != nil {
 		log.Fatal("Error starting server:", err)
@@ -52,8 +87,15 @@ func main() {
 }
 
 type HelloMessage struct {
-	Username string   `json:"username"`
-	Room     RoomInfo `json:"room"`
+	Username  string         `json:"username"`
+	Room      RoomInfo       `json:"room"`
+	Spectator bool           `json:"spectator,omitempty"` // join without controlling playback
+	Features  ClientFeatures `json:"features"`
+}
+
+// ClientFeatures are the optional extensions a client supports
+type ClientFeatures struct {
+	Speed bool `json:"speed"`
 }
 
 type RoomInfo struct {
@@ -170,13 +212,19 @@ func handleHelloMessage(helloMsg *HelloMessage, conn net.Conn) {
 			return
 		}
 	}
-
 	connection, coner := cm.AddConnection(username, roomName, nil, conn)
 	if coner != nil {
 		utils.DebugLog("Error adding connection to room:", coner)
 		messages.SendMessageToUser(username+" is already in the room", "server", conn)
 		return
 	}
+	messages.SetupRoomPolicies(connection.Owner)
+
+	// older clients don't know about speed and keep getting plain playstates
+	connection.SupportsSpeed = Features.GlobalFeatures.Speed && helloMsg.Features.Speed
+
+	connection.RequestedSpectator = helloMsg.Spectator
+	messages.SetupSpectator(*connection, connection.RequestedSpectator)
 
 	err := messages.BroadcastJoinAnnouncement(*connection)
 	if err != nil {
@@ -194,8 +242,6 @@ func handleHelloMessage(helloMsg *HelloMessage, conn net.Conn) {
 	}
 
 	messages.SendInitialState(*connection)
-
-	setupStatusScheduler(*connection)
 }
 
 func handleSetMessage(setMsg *messages.SetMessage, conn net.Conn) {
@@ -219,6 +265,8 @@ func handleSetMessage(setMsg *messages.SetMessage, conn net.Conn) {
 		messages.HandleFileMessage(*usr, setMsg.File)
 	case setMsg.Room != nil:
 		messages.HandleUserMoveRoomMessage(*usr, setMsg.Room)
+	case setMsg.Bookmark != nil:
+		messages.HandleBookmarkMessage(*usr, setMsg.Bookmark)
 	}
 
 }
@@ -260,7 +308,10 @@ func handleStateMessage(stateMsg *messages.ClientStateMessage, conn net.Conn) {
 	clientLatencyCalculation := stateMsg.Ping.ClientLatencyCalculation
 	clientRtt := stateMsg.Ping.ClientRtt
 
-	err = room.SetUserLatencyCalculation(user, float64(time.Now().UnixNano())/1e9, clientLatencyCalculation, clientRtt, latencyCalculation)
+	now := float64(time.Now().UnixNano()) / 1e9
+	user.Latency.Receive(now, latencyCalculation, clientRtt, clientLatencyCalculation)
+
+	err = room.SetUserLatencyCalculation(user, now, clientLatencyCalculation, clientRtt, latencyCalculation)
 	if err != nil {
 		utils.DebugLog("Error storing user latency calculation")
 	}
@@ -269,42 +320,42 @@ func handleStateMessage(stateMsg *messages.ClientStateMessage, conn net.Conn) {
 	if stateMsg.IgnoringOnTheFly != nil {
 		utils.DebugLog("Ignoring on the fly")
 		clientIgnoringOnTheFly = stateMsg.IgnoringOnTheFly.Client
+		user.OnTheFly.Receive(stateMsg.IgnoringOnTheFly.Server, stateMsg.IgnoringOnTheFly.Client)
 	}
 
-	messages.UpdateGlobalState(*user, position, paused, doSeek, setBy, latencyCalculation, 0, clientIgnoringOnTheFly)
+	// the client has not seen the last forced state yet, its playstate is out of date
+	if user.OnTheFly.Ignoring() {
+		return
+	}
 
-	if clientIgnoringOnTheFly != 0 {
-		messages.SendGlobalState(*user)
+	if messages.RejectUnpause(*user, paused) {
+		return
+	}
+
+	if stateMsg.Playstate.Speed != nil && user.SupportsSpeed {
+		messages.UpdateRoomSpeed(*user, *stateMsg.Playstate.Speed, now)
+	}
+
+	err = messages.UpdateGlobalState(*user, position, paused, doSeek, setBy, now, latencyCalculation, clientIgnoringOnTheFly)
+	if err != nil {
+		utils.DebugLog("Error updating room state: %v\n", err)
 	}
 }
 
 func handleChatMessage(chatMsg string, conn net.Conn) {
 	utils.DebugLog("Handling chat message")
 	cm := connM.GetConnectionManager()
-	username := cm.GetRoomByConnection(conn).GetUsernameByConnection(conn)
-	messages.SendChatMessage(chatMsg, username)
-}
-
-func sendSessionInformation(connection roomM.Connection) {
-	messages.SendReadyMessageInit(connection)
-	messages.SendPlaylistChangeMessage(connection, nil)
-	messages.SendPlaylistIndexMessage(connection)
-}
-
-func setupStatusScheduler(connection roomM.Connection) {
-	utils.DebugLog("Setting up status scheduler")
-	room := connection.Owner
-	if room == nil {
-		utils.DebugLog("Error: Room not found")
+	room := cm.GetRoomByConnection(conn)
+	usr, err := room.GetConnectionByConn(conn)
+	if err != nil {
 		return
 	}
 
-	em := room.GetStateEventManager()
-
-	params := []interface{}{connection}
-
-	managedEvent := em.NewManagedEvent(1, messages.SendUserState, true, params, room.GetStateEventTicker())
+	messages.HandleChatMessage(*usr, chatMsg)
+}
 
-	managedEvent.Start()
-	utils.DebugLog("Status scheduler started")
+func sendSessionInformation(connection roomM.Connection) {
+	messages.SendReadyMessageInit(connection)
+	messages.SendPlaylistToUser(connection)
+	messages.SendBookmarksToUser(connection)
 }
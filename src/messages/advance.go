@@ -0,0 +1,110 @@
+package messages
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Icey-Glitch/Syncplay-G/mngr/playlists"
+	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
+	"github.com/Icey-Glitch/Syncplay-G/utils"
+)
+
+// endOfFileThreshold is how close to the end of a file (seconds) counts as finished
+const endOfFileThreshold = 1.0
+
+func init() {
+	registerChatCommand("autoadvance", "/autoadvance on|off - move to the next playlist entry when a file ends (operators only)", handleAutoAdvanceCommand)
+	registerChatCommand("repeat", "/repeat off|one|all - repeat mode for auto-advance (operators only)", handleRepeatCommand)
+	registerChatCommand("shuffle", "/shuffle on|off - pick the next entry at random (operators only)", handleShuffleCommand)
+}
+
+// checkEndOfFile advances the playlist of a room once the current file has ended
+func checkEndOfFile(room *roomM.Room) {
+	settings := room.GetSettings()
+	if !settings.AutoAdvance {
+		return
+	}
+
+	previous, _ := room.PlaylistManager.GetIndex()
+	now := float64(time.Now().UnixNano()) / 1e9
+	index, advanced := room.PlaylistManager.AdvanceAtEndOfFile(now, endOfFileThreshold, settings.Repeat, settings.Shuffle, serverUsername)
+	if !advanced {
+		return
+	}
+
+	broadcastPlaylistIndex(room, serverUsername)
+
+	if index == previous {
+		// same entry again, clients have to seek back to the start themselves
+		broadcastSeek(room, 0)
+	} else if settings.ResetReadyOnIndexChange {
+		resetReadiness(room, roomUsernames(room), "the playlist moved to another file")
+	}
+}
+
+// broadcastPlaylistIndex sends the stored playlist index to everyone in the room
+func broadcastPlaylistIndex(room *roomM.Room, username string) {
+	playlistIndexMessage := PlaylistIndexMessage{}
+	playlistIndexMessage.Set.PlaylistIndex.Index = room.PlaylistManager.GetPlaylist().Index
+	playlistIndexMessage.Set.PlaylistIndex.User = username
+
+	utils.SendJSONMessageMultiCast(playlistIndexMessage, room)
+}
+
+// broadcastSeek seeks the room clock to position and tells everyone in the room
+func broadcastSeek(room *roomM.Room, position float64) {
+	room.PlaylistManager.Seek(position, serverUsername, float64(time.Now().UnixNano())/1e9)
+	broadcastRoomState(room)
+}
+
+func handleAutoAdvanceCommand(connection roomM.Connection, args string) {
+	if !requireOperator(connection) {
+		return
+	}
+
+	if args != "on" && args != "off" {
+		sendServerNotice(connection, "Usage: /autoadvance on|off")
+		return
+	}
+
+	settings := connection.Owner.GetSettings()
+	settings.AutoAdvance = args == "on"
+	connection.Owner.SetSettings(settings)
+
+	SendServerChatMessage(connection.Owner, "Auto-advance: "+args)
+}
+
+func handleRepeatCommand(connection roomM.Connection, args string) {
+	if !requireOperator(connection) {
+		return
+	}
+
+	mode := playlists.RepeatMode(strings.ToLower(args))
+	if mode != playlists.RepeatOff && mode != playlists.RepeatOne && mode != playlists.RepeatAll {
+		sendServerNotice(connection, "Usage: /repeat off|one|all")
+		return
+	}
+
+	settings := connection.Owner.GetSettings()
+	settings.Repeat = mode
+	connection.Owner.SetSettings(settings)
+
+	SendServerChatMessage(connection.Owner, "Repeat: "+string(mode))
+}
+
+func handleShuffleCommand(connection roomM.Connection, args string) {
+	if !requireOperator(connection) {
+		return
+	}
+
+	if args != "on" && args != "off" {
+		sendServerNotice(connection, "Usage: /shuffle on|off")
+		return
+	}
+
+	settings := connection.Owner.GetSettings()
+	settings.Shuffle = args == "on"
+	connection.Owner.SetSettings(settings)
+
+	SendServerChatMessage(connection.Owner, "Shuffle: "+args)
+}
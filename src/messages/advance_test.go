@@ -0,0 +1,62 @@
+package messages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// playlistIndexes returns the Set.playlistIndex payloads among messages
+func playlistIndexes(messages []map[string]interface{}) []map[string]interface{} {
+	indexes := make([]map[string]interface{}, 0)
+	for _, message := range messages {
+		set, ok := message["Set"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if index, ok := set["playlistIndex"].(map[string]interface{}); ok {
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes
+}
+
+func TestAdvanceOnEndOfFileState(t *testing.T) {
+	cm, room := newTestRoom(t)
+	settings := room.GetSettings()
+	settings.AutoAdvance = true
+	room.SetSettings(settings)
+
+	alice, _ := joinTestRoom(t, cm, room, "alice")
+	bob, _ := joinTestRoom(t, cm, room, "bob")
+	_, carolConn := joinTestRoom(t, cm, room, "carol")
+
+	room.PlaylistManager.SetFiles([]string{"a.mkv", "b.mkv"}, alice.Username)
+	assert.NoError(t, room.PlaylistManager.SetIndex(0, alice.Username))
+	for _, name := range []string{"a.mkv", "b.mkv"} {
+		_, err := room.PlaylistManager.AddFile(600, name, 0, alice.Username, "")
+		assert.NoError(t, err)
+	}
+
+	now := float64(time.Now().UnixNano()) / 1e9
+	room.PlaylistManager.SetRoomPaused(false, alice.Username, now)
+	room.PlaylistManager.Seek(599.5, alice.Username, now)
+	carolConn.messages(t)
+
+	// Test case 1: the first user reaching the end advances the room to the next entry
+	assert.NoError(t, UpdateGlobalState(*alice, 599.8, false, false, "alice", now, now, 0))
+	index, _ := room.PlaylistManager.GetIndex()
+	assert.Equal(t, 1, index)
+
+	// Test case 2: later reports of the old file's end don't advance it again
+	assert.NoError(t, UpdateGlobalState(*bob, 599.9, false, false, "bob", now, now, 0))
+	index, _ = room.PlaylistManager.GetIndex()
+	assert.Equal(t, 1, index)
+
+	indexes := playlistIndexes(carolConn.messages(t))
+	if assert.Len(t, indexes, 1) {
+		assert.Equal(t, 1.0, indexes[0]["index"])
+		assert.Equal(t, serverUsername, indexes[0]["user"])
+	}
+}
@@ -0,0 +1,134 @@
+package messages
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
+)
+
+func init() {
+	registerChatCommand("autoplay", "/autoplay on|off [seconds] - start playback when everyone is ready (operators only)", handleAutoPlayCommand)
+}
+
+// autoPlayer unpauses a room after a countdown once every user is ready
+type autoPlayer struct {
+	room   *roomM.Room
+	cancel chan struct{}
+	mutex  sync.Mutex
+}
+
+// startAutoPlay watches the readiness of a room until stop is closed
+func startAutoPlay(room *roomM.Room, stop chan struct{}) {
+	ap := &autoPlayer{room: room}
+	ch := room.ReadyManager.SubscribeToStateChanges()
+
+	go func() {
+		defer room.ReadyManager.UnsubscribeFromStateChanges(ch)
+
+		for {
+			select {
+			case <-ch:
+				ap.evaluate()
+			case <-stop:
+				ap.stop()
+				return
+			}
+		}
+	}()
+}
+
+// stop cancels a running countdown without telling the room, which is empty
+func (ap *autoPlayer) stop() {
+	ap.mutex.Lock()
+	defer ap.mutex.Unlock()
+
+	if ap.cancel != nil {
+		close(ap.cancel)
+		ap.cancel = nil
+	}
+}
+
+// evaluate starts or cancels the countdown after a readiness change
+func (ap *autoPlayer) evaluate() {
+	ap.mutex.Lock()
+	defer ap.mutex.Unlock()
+
+	settings := ap.room.GetSettings()
+	ready := settings.AutoPlay && ap.room.AllUsersReady() && ap.room.PlaylistManager.GetUserPauseState()
+
+	switch {
+	case ready && ap.cancel == nil:
+		ap.cancel = make(chan struct{})
+		go ap.countdown(settings.AutoPlayCountdown, ap.cancel)
+	case !ready && ap.cancel != nil:
+		close(ap.cancel)
+		ap.cancel = nil
+		SendServerChatMessage(ap.room, "Auto-play cancelled, not everyone is ready")
+	}
+}
+
+func (ap *autoPlayer) countdown(seconds int, cancel chan struct{}) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for remaining := seconds; remaining > 0; remaining-- {
+		SendServerChatMessage(ap.room, fmt.Sprintf("Everyone is ready, starting playback in %d...", remaining))
+		select {
+		case <-ticker.C:
+		case <-cancel:
+			return
+		}
+	}
+
+	ap.mutex.Lock()
+	defer ap.mutex.Unlock()
+
+	select {
+	case <-cancel:
+		return
+	default:
+	}
+	ap.cancel = nil
+
+	// someone may have unpaused by hand during the countdown
+	if !ap.room.PlaylistManager.GetUserPauseState() || !ap.room.AllUsersReady() {
+		return
+	}
+
+	ap.room.PlaylistManager.SetRoomPaused(false, serverUsername, float64(time.Now().UnixNano())/1e9)
+	broadcastRoomState(ap.room)
+}
+
+func handleAutoPlayCommand(connection roomM.Connection, args string) {
+	if !requireOperator(connection) {
+		return
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) == 0 || (fields[0] != "on" && fields[0] != "off") {
+		sendServerNotice(connection, "Usage: /autoplay on|off [seconds]")
+		return
+	}
+
+	settings := connection.Owner.GetSettings()
+	settings.AutoPlay = fields[0] == "on"
+	if len(fields) > 1 {
+		seconds, err := strconv.Atoi(fields[1])
+		if err != nil || seconds < 0 {
+			sendServerNotice(connection, "Usage: /autoplay on|off [seconds]")
+			return
+		}
+		settings.AutoPlayCountdown = seconds
+	}
+	connection.Owner.SetSettings(settings)
+
+	if settings.AutoPlay {
+		SendServerChatMessage(connection.Owner, fmt.Sprintf("Auto-play enabled, playback starts %ds after everyone is ready", settings.AutoPlayCountdown))
+	} else {
+		SendServerChatMessage(connection.Owner, "Auto-play disabled")
+	}
+}
@@ -0,0 +1,75 @@
+package messages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoPlay(t *testing.T) {
+	cm, room := newTestRoom(t)
+	settings := room.GetSettings()
+	settings.AutoPlay = true
+	settings.AutoPlayCountdown = 1
+	room.SetSettings(settings)
+
+	alice, aliceConn := joinTestRoom(t, cm, room, "alice")
+	bob, _ := joinTestRoom(t, cm, room, "bob")
+
+	stop := make(chan struct{})
+	startAutoPlay(room, stop)
+	t.Cleanup(func() { close(stop) })
+
+	chats := make([]string, 0)
+	waitForChat := func(message string) {
+		assert.Eventually(t, func() bool {
+			chats = append(chats, aliceConn.chats(t)...)
+			return containsString(chats, message)
+		}, 2*time.Second, 10*time.Millisecond, message)
+	}
+
+	// Test case 1: the countdown is cancelled when someone un-readies, and the room stays paused
+	room.SetUserReadyState(alice.Username, true, true)
+	room.SetUserReadyState(bob.Username, true, true)
+	waitForChat("Everyone is ready, starting playback in 1...")
+	room.SetUserReadyState(bob.Username, false, true)
+	waitForChat("Auto-play cancelled, not everyone is ready")
+	time.Sleep(1500 * time.Millisecond)
+	assert.True(t, room.PlaylistManager.GetUserPauseState())
+
+	// Test case 2: once everyone is ready again the room unpauses when the countdown ends
+	room.SetUserReadyState(bob.Username, true, true)
+	assert.Eventually(t, func() bool { return !room.PlaylistManager.GetUserPauseState() }, 3*time.Second, 10*time.Millisecond)
+	assert.Equal(t, serverUsername, room.PlaylistManager.GetClock(float64(time.Now().UnixNano())/1e9).SetBy)
+}
+
+func TestAutoPlayCancelledWhenRoomEmpties(t *testing.T) {
+	cm, room := newTestRoom(t)
+	settings := room.GetSettings()
+	settings.AutoPlay = true
+	settings.AutoPlayCountdown = 1
+	room.SetSettings(settings)
+
+	alice, aliceConn := joinTestRoom(t, cm, room, "alice")
+	SetupRoomPolicies(room)
+
+	// Test case 1: the countdown stops with the room's policies when the last user leaves
+	room.SetUserReadyState(alice.Username, true, true)
+	assert.Eventually(t, func() bool {
+		return containsString(aliceConn.chats(t), "Everyone is ready, starting playback in 1...")
+	}, 2*time.Second, 10*time.Millisecond)
+	room.RemoveConnection(alice.Conn)
+	assert.Eventually(t, func() bool { return !policiesRunning(room) }, time.Second, 10*time.Millisecond)
+	time.Sleep(1500 * time.Millisecond)
+	assert.True(t, room.PlaylistManager.GetUserPauseState())
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package messages
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Icey-Glitch/Syncplay-G/mngr/playlists"
+	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
+)
+
+func init() {
+	registerChatCommand("bookmark", "/bookmark <name> [h:mm:ss] | /bookmark remove <name> - mark a position in the current entry", handleBookmarkCommand)
+	registerChatCommand("bookmarks", "/bookmarks - list the bookmarks of the current entry", handleBookmarksCommand)
+	registerChatCommand("goto", "/goto <name> - seek everyone to a bookmark", handleGotoCommand)
+}
+
+// ClientBookmarkMessage adds or removes a bookmark, without a position the room position is used
+type ClientBookmarkMessage struct {
+	Name     string   `json:"name"`
+	Position *float64 `json:"position,omitempty"`
+	Remove   bool     `json:"remove,omitempty"`
+}
+
+// HandleBookmarkMessage handles {"Set": {"bookmark": {"name": "intro", "position": 3733.0}}}
+func HandleBookmarkMessage(connection roomM.Connection, msg *ClientBookmarkMessage) {
+	if msg.Remove {
+		removeBookmark(connection, msg.Name)
+		return
+	}
+
+	if msg.Position == nil {
+		addBookmark(connection, msg.Name, roomPosition(connection.Owner))
+		return
+	}
+	addBookmark(connection, msg.Name, *msg.Position)
+}
+
+// SendBookmarksToUser lists the bookmarks of the current entry to a user, if there are any
+func SendBookmarksToUser(connection roomM.Connection) {
+	if connection.Owner == nil {
+		return
+	}
+
+	if bookmarks := connection.Owner.PlaylistManager.GetBookmarks(); len(bookmarks) > 0 {
+		sendServerNotice(connection, describeBookmarks(bookmarks))
+	}
+}
+
+func addBookmark(connection roomM.Connection, name string, position float64) {
+	if rejectSpectator(connection, "bookmarks") {
+		return
+	}
+
+	err := connection.Owner.PlaylistManager.AddBookmark(name, position, connection.Username)
+	if err != nil {
+		sendServerNotice(connection, "Can't add bookmark: "+err.Error())
+		return
+	}
+
+	SendServerChatMessage(connection.Owner, fmt.Sprintf("%s bookmarked %s at %s, use /goto %s", connection.Username, name, formatPosition(position), name))
+}
+
+func removeBookmark(connection roomM.Connection, name string) {
+	if rejectSpectator(connection, "bookmarks") {
+		return
+	}
+
+	room := connection.Owner
+	bookmark, ok := room.PlaylistManager.FindBookmark(name)
+	if !ok {
+		sendServerNotice(connection, "There is no bookmark named "+name)
+		return
+	}
+
+	// anyone may remove their own bookmarks, operators everyone's
+	if bookmark.SetBy != connection.Username && !room.IsOperator(connection.Username) {
+		sendServerNotice(connection, "Only "+bookmark.SetBy+" or an operator can remove "+bookmark.Name)
+		return
+	}
+
+	if _, err := room.PlaylistManager.RemoveBookmark(name); err != nil {
+		sendServerNotice(connection, err.Error())
+		return
+	}
+
+	SendServerChatMessage(room, connection.Username+" removed bookmark "+bookmark.Name)
+}
+
+// roomPosition returns the position of the room clock now
+func roomPosition(room *roomM.Room) float64 {
+	return room.PlaylistManager.GetClock(float64(time.Now().UnixNano()) / 1e9).Position
+}
+
+// describeBookmarks turns bookmarks into "Bookmarks: intro 0:01:30, fight 1:02:13"
+func describeBookmarks(bookmarks []playlists.Bookmark) string {
+	parts := make([]string, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		parts = append(parts, bookmark.Name+" "+formatPosition(bookmark.Position))
+	}
+	return "Bookmarks: " + strings.Join(parts, ", ")
+}
+
+// formatPosition formats seconds as h:mm:ss
+func formatPosition(position float64) string {
+	seconds := int(math.Floor(position))
+	return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+// parsePosition parses seconds, m:ss or h:mm:ss
+func parsePosition(value string) (float64, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time %s", value)
+	}
+
+	position := 0.0
+	for i, part := range parts {
+		number, err := strconv.ParseFloat(part, 64)
+		if err != nil || number < 0 || (i > 0 && number >= 60) {
+			return 0, fmt.Errorf("invalid time %s", value)
+		}
+		position = position*60 + number
+	}
+	return position, nil
+}
+
+func handleBookmarkCommand(connection roomM.Connection, args string) {
+	fields := strings.Fields(args)
+	switch {
+	case len(fields) == 2 && fields[0] == "remove":
+		removeBookmark(connection, fields[1])
+	case len(fields) == 1:
+		addBookmark(connection, fields[0], roomPosition(connection.Owner))
+	case len(fields) == 2:
+		position, err := parsePosition(fields[1])
+		if err != nil {
+			sendServerNotice(connection, "Usage: /bookmark <name> [h:mm:ss]")
+			return
+		}
+		addBookmark(connection, fields[0], position)
+	default:
+		sendServerNotice(connection, "Usage: /bookmark <name> [h:mm:ss] | /bookmark remove <name>")
+	}
+}
+
+func handleBookmarksCommand(connection roomM.Connection, args string) {
+	bookmarks := connection.Owner.PlaylistManager.GetBookmarks()
+	if len(bookmarks) == 0 {
+		sendServerNotice(connection, "The current entry has no bookmarks")
+		return
+	}
+	sendServerNotice(connection, describeBookmarks(bookmarks))
+}
+
+func handleGotoCommand(connection roomM.Connection, args string) {
+	name := strings.TrimSpace(args)
+	if name == "" {
+		sendServerNotice(connection, "Usage: /goto <name>")
+		return
+	}
+
+	if rejectSpectator(connection, "playback") {
+		return
+	}
+
+	room := connection.Owner
+	if presenter := room.GetPresenter(); presenter != "" && presenter != connection.Username {
+		sendServerNotice(connection, "Playback follows "+presenter)
+		return
+	}
+
+	bookmark, ok := room.PlaylistManager.FindBookmark(name)
+	if !ok {
+		sendServerNotice(connection, "There is no bookmark named "+name)
+		return
+	}
+
+	resetReadinessOnSeek(connection, bookmark.Position, true)
+	room.PlaylistManager.Seek(bookmark.Position, connection.Username, float64(time.Now().UnixNano())/1e9)
+	broadcastRoomState(room)
+
+	SendServerChatMessage(room, connection.Username+" jumped to "+bookmark.Name+" at "+formatPosition(bookmark.Position))
+}
@@ -0,0 +1,46 @@
+package messages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGotoCommand(t *testing.T) {
+	cm, room := newTestRoom(t)
+	alice, _ := joinTestRoom(t, cm, room, "alice")
+	bob, bobConn := joinTestRoom(t, cm, room, "bob")
+	carol, carolConn := joinTestRoom(t, cm, room, "carol")
+	assert.False(t, room.IsOperator(bob.Username))
+	assert.NoError(t, room.PlaylistManager.AddBookmark("intro", 90, alice.Username))
+
+	now := func() float64 { return float64(time.Now().UnixNano()) / 1e9 }
+
+	// Test case 1: anyone who controls playback, not only operators, can jump to a bookmark
+	HandleChatCommand(*bob, "/goto intro")
+	assert.InDelta(t, 90, room.PlaylistManager.GetClock(now()).Position, 0.5)
+	state := lastState(t, carolConn.messages(t))
+	if assert.NotNil(t, state) {
+		assert.Equal(t, 90.0, state["position"])
+		assert.Equal(t, true, state["doSeek"])
+		assert.Equal(t, "bob", state["setBy"])
+	}
+	bobConn.messages(t)
+
+	// Test case 2: a missing bookmark leaves the room where it is
+	HandleChatCommand(*bob, "/goto outro")
+	assert.InDelta(t, 90, room.PlaylistManager.GetClock(now()).Position, 0.5)
+	assert.Equal(t, []string{"There is no bookmark named outro"}, bobConn.chats(t))
+	assert.Nil(t, lastState(t, carolConn.messages(t)))
+
+	// Test case 3: spectators and users following a presenter are refused
+	room.AddSpectator(carol.Username)
+	HandleChatCommand(*carol, "/goto intro")
+	assert.Equal(t, []string{"Spectators can't change playback"}, carolConn.chats(t))
+
+	room.SetPresenter(alice.Username)
+	HandleChatCommand(*bob, "/goto intro")
+	assert.Equal(t, []string{"Playback follows alice"}, bobConn.chats(t))
+	assert.Nil(t, lastState(t, carolConn.messages(t)))
+}
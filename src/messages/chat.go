@@ -1,9 +1,15 @@
 package messages
 
 import (
+	"fmt"
 	"net"
+	"strings"
+	"time"
 
+	Features "github.com/Icey-Glitch/Syncplay-G/features"
+	"github.com/Icey-Glitch/Syncplay-G/mngr/chatlog"
 	connM "github.com/Icey-Glitch/Syncplay-G/mngr/conn"
+	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
 	"github.com/Icey-Glitch/Syncplay-G/utils"
 )
 
@@ -21,13 +27,71 @@ type ClientChatMessage struct {
 	Chat string `json:"chat"`
 }
 
+// chatLogger records room chat for moderation, nil when logging is disabled
+var chatLogger *chatlog.Logger
+
+// SetChatLogger sets the logger that receives every broadcast chat message
+func SetChatLogger(logger *chatlog.Logger) {
+	chatLogger = logger
+}
+
+func init() {
+	registerChatCommand("msg", "/msg <user> <text> - send a private message", handleMsgCommand)
+	registerChatCommand("block", "/block <user> - stop receiving private messages from a user", handleBlockCommand)
+	registerChatCommand("unblock", "/unblock <user> - receive private messages from a user again", handleUnblockCommand)
+}
+
+// HandleChatMessage handles a chat message from a client, running it as a command if it starts with "/"
+func HandleChatMessage(connection roomM.Connection, message string) {
+	if HandleChatCommand(connection, message) {
+		return
+	}
+
+	if connection.Owner != nil && connection.Owner.IsMuted(connection.Username) {
+		sendServerNotice(connection, "You are muted in this room")
+		return
+	}
+
+	if connection.Owner != nil && connection.Owner.IsSpectator(connection.Username) && !connection.Owner.GetSettings().SpectatorChat {
+		sendServerNotice(connection, "Spectators can't chat in this room")
+		return
+	}
+
+	SendChatMessage(message, connection.Username)
+}
+
 func SendChatMessage(message, username string) {
 	room := connM.GetConnectionManager().GetRoomByUsername(username)
+	if room == nil {
+		return
+	}
+
 	chatMessage := ChatMessage{}
 	chatMessage.Chat.Message = message
 	chatMessage.Chat.Username = username
 
 	utils.SendJSONMessageMultiCast(chatMessage, room)
+
+	// queued, never blocks delivery
+	chatLogger.Log(chatlog.Entry{
+		Timestamp: time.Now(),
+		Username:  username,
+		Room:      room.Name,
+		Message:   message,
+	})
+}
+
+// SendServerChatMessage sends a chat message from the server to everyone in the room
+func SendServerChatMessage(room *roomM.Room, message string) {
+	if room == nil {
+		return
+	}
+
+	chatMessage := ChatMessage{}
+	chatMessage.Chat.Message = message
+	chatMessage.Chat.Username = serverUsername
+
+	utils.SendJSONMessageMultiCast(chatMessage, room)
 }
 
 func SendMessageToUser(message string, username string, conn net.Conn) {
@@ -40,3 +104,83 @@ func SendMessageToUser(message string, username string, conn net.Conn) {
 		return
 	}
 }
+
+// SendDirectMessage delivers a chat message from sender to a single user.
+// With isolated rooms the target must be in the sender's room.
+func SendDirectMessage(sender roomM.Connection, target string, message string) error {
+	if message == "" {
+		return fmt.Errorf("message cannot be empty")
+	}
+
+	if sender.Owner != nil && sender.Owner.IsMuted(sender.Username) {
+		return fmt.Errorf("you are muted in this room")
+	}
+
+	cm := connM.GetConnectionManager()
+	var recipient *roomM.Connection
+	if Features.GlobalFeatures.IsolateRooms {
+		if sender.Owner != nil {
+			if room := cm.GetRoom(sender.Owner.Name); room != nil {
+				recipient = room.GetConnectionByUsername(target)
+			}
+		}
+	} else {
+		recipient = cm.FindConnection(target)
+	}
+
+	if recipient == nil || recipient.Conn == nil {
+		return fmt.Errorf("user %s not found", target)
+	}
+
+	// don't tell the sender they are blocked
+	if recipient.BlockList.IsBlocked(sender.Username) {
+		return fmt.Errorf("could not deliver message to %s", target)
+	}
+
+	chatMessage := ChatMessage{}
+	chatMessage.Chat.Message = "(private) " + message
+	chatMessage.Chat.Username = sender.Username
+
+	if err := utils.SendJSONMessage(recipient.Conn, chatMessage); err != nil {
+		return fmt.Errorf("could not deliver message to %s", target)
+	}
+
+	return nil
+}
+
+func handleMsgCommand(connection roomM.Connection, args string) {
+	target, text, _ := strings.Cut(args, " ")
+	text = strings.TrimSpace(text)
+	if target == "" || text == "" {
+		sendServerNotice(connection, "Usage: /msg <user> <text>")
+		return
+	}
+
+	if err := SendDirectMessage(connection, target, text); err != nil {
+		sendServerNotice(connection, err.Error())
+		return
+	}
+
+	// echo back so the sender sees their own message
+	SendMessageToUser("(private to "+target+") "+text, connection.Username, connection.Conn)
+}
+
+func handleBlockCommand(connection roomM.Connection, args string) {
+	if args == "" || connection.BlockList == nil {
+		sendServerNotice(connection, "Usage: /block <user>")
+		return
+	}
+
+	connection.BlockList.Block(args)
+	sendServerNotice(connection, "You will no longer receive private messages from "+args)
+}
+
+func handleUnblockCommand(connection roomM.Connection, args string) {
+	if args == "" || connection.BlockList == nil {
+		sendServerNotice(connection, "Usage: /unblock <user>")
+		return
+	}
+
+	connection.BlockList.Unblock(args)
+	sendServerNotice(connection, "You will receive private messages from "+args+" again")
+}
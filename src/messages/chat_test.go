@@ -0,0 +1,47 @@
+package messages
+
+import (
+	"testing"
+
+	Features "github.com/Icey-Glitch/Syncplay-G/features"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirectMessages(t *testing.T) {
+	cm, room := newGlobalTestRoom(t)
+	other := cm.CreateRoom(t.Name() + "-other")
+
+	alice, aliceConn := joinTestRoom(t, cm, room, "alice")
+	bob, bobConn := joinTestRoom(t, cm, room, "bob")
+	carol, _ := joinTestRoom(t, cm, other, "carol")
+	t.Cleanup(func() {
+		cm.RemoveConnection(alice.Conn)
+		cm.RemoveConnection(bob.Conn)
+		cm.RemoveConnection(carol.Conn)
+	})
+
+	// Test case 1: a private message only reaches its target
+	HandleChatCommand(*bob, "/msg alice hello")
+	assert.Equal(t, []string{"(private) hello"}, aliceConn.chats(t))
+	assert.Equal(t, []string{"(private to alice) hello"}, bobConn.chats(t))
+
+	// Test case 2: messages from a blocked sender are dropped
+	HandleChatCommand(*alice, "/block bob")
+	aliceConn.messages(t)
+	HandleChatCommand(*bob, "/msg alice hello again")
+	assert.Empty(t, aliceConn.chats(t))
+	assert.Equal(t, []string{"could not deliver message to alice"}, bobConn.chats(t))
+
+	HandleChatCommand(*alice, "/unblock bob")
+	aliceConn.messages(t)
+	HandleChatCommand(*bob, "/msg alice hello again")
+	assert.Equal(t, []string{"(private) hello again"}, aliceConn.chats(t))
+	bobConn.messages(t)
+
+	// Test case 3: users in other rooms are only reachable when rooms are not isolated
+	Features.GlobalFeatures.IsolateRooms = true
+	assert.Error(t, SendDirectMessage(*bob, carol.Username, "hello"))
+
+	Features.GlobalFeatures.IsolateRooms = false
+	assert.NoError(t, SendDirectMessage(*bob, carol.Username, "hello"))
+}
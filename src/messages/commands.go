@@ -0,0 +1,59 @@
+package messages
+
+import (
+	"sort"
+	"strings"
+
+	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
+)
+
+// serverUsername is shown as the sender of server notices
+const serverUsername = "server"
+
+type chatCommand struct {
+	usage   string
+	handler func(connection roomM.Connection, args string)
+}
+
+var chatCommands = make(map[string]chatCommand)
+
+// registerChatCommand adds a "/name" command to the chat command table
+func registerChatCommand(name string, usage string, handler func(connection roomM.Connection, args string)) {
+	chatCommands[name] = chatCommand{usage: usage, handler: handler}
+}
+
+func init() {
+	registerChatCommand("help", "/help - list available commands", handleHelpCommand)
+}
+
+// HandleChatCommand runs a "/command args" chat message, returns false if the message is not a command
+func HandleChatCommand(connection roomM.Connection, message string) bool {
+	if !strings.HasPrefix(message, "/") {
+		return false
+	}
+
+	name, args, _ := strings.Cut(strings.TrimPrefix(message, "/"), " ")
+	command, ok := chatCommands[strings.ToLower(name)]
+	if !ok {
+		sendServerNotice(connection, "Unknown command /"+name+", type /help for a list of commands")
+		return true
+	}
+
+	command.handler(connection, strings.TrimSpace(args))
+	return true
+}
+
+func handleHelpCommand(connection roomM.Connection, _ string) {
+	usages := make([]string, 0, len(chatCommands))
+	for _, command := range chatCommands {
+		usages = append(usages, command.usage)
+	}
+	sort.Strings(usages)
+
+	sendServerNotice(connection, strings.Join(usages, "\n"))
+}
+
+// sendServerNotice sends a chat message from the server to a single user
+func sendServerNotice(connection roomM.Connection, message string) {
+	SendMessageToUser(message, serverUsername, connection.Conn)
+}
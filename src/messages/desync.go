@@ -0,0 +1,106 @@
+package messages
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
+)
+
+func init() {
+	registerChatCommand("desync", "/desync [range|notice <seconds>] - show how far everyone is from the room, or set the thresholds (operators only)", handleDesyncCommand)
+}
+
+// checkDesync tells the room when a user drifts out of sync or catches up again, and applies the
+// room's lag policy
+func checkDesync(room *roomM.Room, username string) {
+	defer checkLag(room, username)
+
+	threshold := room.GetSettings().DesyncNotice
+	if threshold <= 0 {
+		return
+	}
+
+	offset, desynced, changed := room.PlaylistManager.CheckDesync(username, threshold, float64(time.Now().UnixNano())/1e9)
+	if !changed {
+		return
+	}
+
+	if desynced {
+		SendServerChatMessage(room, username+" is "+describeOffset(offset))
+	} else {
+		SendServerChatMessage(room, username+" is back in sync")
+	}
+}
+
+// describeOffset turns an offset from the room clock into "4.2s behind" or "1.0s ahead"
+func describeOffset(offset float64) string {
+	direction := "ahead"
+	if offset < 0 {
+		direction = "behind"
+	}
+	return fmt.Sprintf("%.1fs %s", math.Abs(offset), direction)
+}
+
+// desyncSummary lists how far every user is from the room clock
+func desyncSummary(room *roomM.Room) string {
+	offsets := room.PlaylistManager.Offsets(float64(time.Now().UnixNano()) / 1e9)
+	if len(offsets) == 0 {
+		return "Nobody is playing a file that can be compared"
+	}
+
+	usernames := make([]string, 0, len(offsets))
+	for username := range offsets {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+
+	parts := make([]string, 0, len(usernames))
+	for _, username := range usernames {
+		parts = append(parts, username+" "+describeOffset(offsets[username]))
+	}
+	return "Offsets from the room: " + strings.Join(parts, ", ")
+}
+
+func handleDesyncCommand(connection roomM.Connection, args string) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		settings := connection.Owner.GetSettings()
+		sendServerNotice(connection, fmt.Sprintf("%s (range %gs, notice %gs)", desyncSummary(connection.Owner), settings.DesyncRange, settings.DesyncNotice))
+		return
+	}
+
+	if !requireOperator(connection) {
+		return
+	}
+
+	usage := "Usage: /desync [range|notice <seconds>]"
+	if len(fields) != 2 {
+		sendServerNotice(connection, usage)
+		return
+	}
+
+	seconds, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || seconds < 0 {
+		sendServerNotice(connection, usage)
+		return
+	}
+
+	settings := connection.Owner.GetSettings()
+	switch fields[0] {
+	case "range":
+		settings.DesyncRange = seconds
+	case "notice":
+		settings.DesyncNotice = seconds
+	default:
+		sendServerNotice(connection, usage)
+		return
+	}
+	connection.Owner.SetSettings(settings)
+
+	SendServerChatMessage(connection.Owner, fmt.Sprintf("Desync %s set to %gs", fields[0], seconds))
+}
@@ -0,0 +1,107 @@
+package messages
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Icey-Glitch/Syncplay-G/mngr/playlists"
+	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
+)
+
+// historyLines is how many edits /history shows
+const historyLines = 10
+
+func init() {
+	registerChatCommand("undo", "/undo - revert the last playlist change", handleUndoCommand)
+	registerChatCommand("redo", "/redo - restore the last undone playlist change", handleRedoCommand)
+	registerChatCommand("history", "/history - show recent playlist changes", handleHistoryCommand)
+}
+
+func handleUndoCommand(connection roomM.Connection, _ string) {
+	if connection.Owner == nil || rejectSpectatorPlaylist(connection) {
+		return
+	}
+
+	files, err := connection.Owner.PlaylistManager.Undo(connection.Username)
+	if err != nil {
+		sendServerNotice(connection, err.Error())
+		return
+	}
+
+	SendPlaylistChangeMessage(connection, files)
+	SendServerChatMessage(connection.Owner, connection.Username+" undid the last playlist change")
+}
+
+func handleRedoCommand(connection roomM.Connection, _ string) {
+	if connection.Owner == nil || rejectSpectatorPlaylist(connection) {
+		return
+	}
+
+	files, err := connection.Owner.PlaylistManager.Redo(connection.Username)
+	if err != nil {
+		sendServerNotice(connection, err.Error())
+		return
+	}
+
+	SendPlaylistChangeMessage(connection, files)
+	SendServerChatMessage(connection.Owner, connection.Username+" redid a playlist change")
+}
+
+func handleHistoryCommand(connection roomM.Connection, _ string) {
+	if connection.Owner == nil {
+		return
+	}
+
+	history := connection.Owner.PlaylistManager.GetHistory()
+	if len(history) == 0 {
+		sendServerNotice(connection, "No playlist changes yet")
+		return
+	}
+
+	if len(history) > historyLines {
+		history = history[len(history)-historyLines:]
+	}
+
+	lines := make([]string, 0, len(history))
+	for _, edit := range history {
+		lines = append(lines, fmt.Sprintf("%s %s: %s", edit.Time.Format("15:04:05"), edit.Author, describeEdit(edit)))
+	}
+	sendServerNotice(connection, strings.Join(lines, "\n"))
+}
+
+// describeEdit summarises what a playlist edit changed
+func describeEdit(edit playlists.PlaylistEdit) string {
+	added := difference(edit.After, edit.Before)
+	removed := difference(edit.Before, edit.After)
+
+	parts := make([]string, 0, 3)
+	if edit.Action != "edit" {
+		parts = append(parts, edit.Action)
+	}
+	if len(added) > 0 {
+		parts = append(parts, "added "+strings.Join(added, ", "))
+	}
+	if len(removed) > 0 {
+		parts = append(parts, "removed "+strings.Join(removed, ", "))
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		parts = append(parts, "reordered the playlist")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// difference returns the names in a that are not in b
+func difference(a []string, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, name := range b {
+		inB[name] = true
+	}
+
+	result := make([]string, 0)
+	for _, name := range a {
+		if !inB[name] {
+			result = append(result, name)
+		}
+	}
+	return result
+}
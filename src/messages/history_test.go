@@ -0,0 +1,28 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpectatorsCantUndo(t *testing.T) {
+	cm, room := newTestRoom(t)
+	alice, _ := joinTestRoom(t, cm, room, "alice")
+	bob, bobConn := joinTestRoom(t, cm, room, "bob")
+	room.AddSpectator(bob.Username)
+
+	room.PlaylistManager.SetFiles([]string{"a.mkv"}, alice.Username)
+	room.PlaylistManager.SetFiles([]string{"a.mkv", "b.mkv"}, alice.Username)
+
+	// Test case 1: a spectator's undo is refused
+	HandleChatCommand(*bob, "/undo")
+	assert.Equal(t, []string{"a.mkv", "b.mkv"}, room.PlaylistManager.GetFileNames())
+	assert.Contains(t, bobConn.chats(t), "Spectators can't change the playlist")
+
+	// Test case 2: so is a redo after someone else undid
+	HandleChatCommand(*alice, "/undo")
+	assert.Equal(t, []string{"a.mkv"}, room.PlaylistManager.GetFileNames())
+	HandleChatCommand(*bob, "/redo")
+	assert.Equal(t, []string{"a.mkv"}, room.PlaylistManager.GetFileNames())
+}
@@ -45,6 +45,9 @@ func HandleJoinMessage(conn net.Conn, msg map[string]interface{}) {
 
 			fmt.Println("Moved user to new room")
 
+			SetupRoomPolicies(connection.Owner)
+			SetupSpectator(*connection, connection.RequestedSpectator)
+
 			// send a join message
 			err = BroadcastJoinAnnouncement(*connection)
 			if err != nil {
@@ -59,6 +62,8 @@ func HandleJoinMessage(conn net.Conn, msg map[string]interface{}) {
 			fmt.Println("Error adding connection to room:", err)
 			return
 		}
+		SetupRoomPolicies(connection.Owner)
+		SetupSpectator(*connection, connection.RequestedSpectator)
 		BroadcastUserRoomChangeMessage(*connection, roomName)
 		return
 	}
@@ -86,11 +91,13 @@ func HandleUserMoveRoomMessage(connection roomM.Connection, msg *RoomMessage) {
 	if oldRoom.Name != newRoom.Name {
 
 		HandleUserLeftMessage(connection)
-		_, err := cm.MoveConnection(connection.Username, newRoom.Name, oldRoom.Name, connection.Conn)
+		moved, err := cm.MoveConnection(connection.Username, newRoom.Name, oldRoom.Name, connection.Conn)
 		if err != nil {
 			fmt.Println("Error moving connection to new room:", err)
 			return
 		}
+		SetupRoomPolicies(moved.Owner)
+		SetupSpectator(*moved, moved.RequestedSpectator)
 
 		// err = BroadcastJoinAnnouncement(connection)
 		// if err != nil {
@@ -111,6 +118,9 @@ func HandleUserLeftMessage(connection roomM.Connection) {
 		return
 	}
 
+	announcePresenterLeft(connection)
+	applyLeavePolicy(connection)
+
 	err := broadcastLeaveAnnouncement(connection)
 	if err != nil {
 		fmt.Printf("Failed to send Leave Anouncement" + err.Error())
@@ -170,5 +180,7 @@ func BroadcastJoinAnnouncement(connection roomM.Connection) error {
 	}
 	utils.SendJSONMessageMultiCast(announcement, connection.Owner)
 
+	pauseForJoiner(connection)
+
 	return nil
 }
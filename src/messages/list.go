@@ -3,6 +3,7 @@ package messages
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	Features "github.com/Icey-Glitch/Syncplay-G/features"
 	"github.com/Icey-Glitch/Syncplay-G/utils"
@@ -47,6 +48,24 @@ type PlayerInfo struct {
 	Controller bool        `json:"controller"`
 	IsReady    bool        `json:"isReady"`
 	Features   FeaturesList
+
+	// own playlist, only included for the requesting user when shared playlists are off
+	Playlist *UserPlaylistInfo `json:"playlist,omitempty"`
+
+	// properties in which this user's file differs from the rest of the room
+	FileMismatch []string `json:"fileMismatch,omitempty"`
+
+	// seconds from the room clock, negative when behind, only for users whose sync can be judged
+	Offset   *float64 `json:"offset,omitempty"`
+	Desynced bool     `json:"desynced,omitempty"`
+
+	Presenter bool `json:"presenter,omitempty"`
+	Spectator bool `json:"spectator,omitempty"`
+}
+
+type UserPlaylistInfo struct {
+	Files []string `json:"files"`
+	Index *int     `json:"index"`
 }
 
 type FeaturesList struct {
@@ -87,6 +106,10 @@ func HandleListRequest(connection roomM.Connection) {
 	// global features
 	var features = Features.GlobalFeatures
 
+	var mismatches = connection.Owner.PlaylistManager.FileMismatches()
+
+	var offsets = connection.Owner.PlaylistManager.Offsets(float64(time.Now().UnixNano()) / 1e9)
+
 	// Use a mutex to ensure thread-safe access to shared resources
 	var mutex sync.RWMutex
 
@@ -109,7 +132,9 @@ func HandleListRequest(connection roomM.Connection) {
 		}
 
 		playerInfo.Position = &user.Position
-		playerInfo.Controller = false
+		playerInfo.Controller = connection.Owner.IsOperator(user.Username)
+		playerInfo.Presenter = connection.Owner.GetPresenter() == user.Username
+		playerInfo.Spectator = connection.Owner.IsSpectator(user.Username)
 		playerInfo.IsReady = readyStates[user.Username].IsReady
 		playerInfo.Features = FeaturesList{
 			SharedPlaylists: features.SharedPlaylists,
@@ -121,6 +146,22 @@ func HandleListRequest(connection roomM.Connection) {
 			PersistentRooms: features.PersistentRooms,
 		}
 
+		if diff, ok := mismatches[user.Username]; ok {
+			playerInfo.FileMismatch = diff.Fields()
+		}
+
+		if offset, ok := offsets[user.Username]; ok {
+			playerInfo.Offset = &offset
+			playerInfo.Desynced = user.Desynced
+		}
+
+		if !features.SharedPlaylists && user.Username == connection.Username {
+			playerInfo.Playlist = &UserPlaylistInfo{
+				Files: connection.Owner.PlaylistManager.GetUserFileNames(user.Username),
+				Index: user.UsrIndex,
+			}
+		}
+
 		// Lock the mutex for writing
 		mutex.Lock()
 		// Check if the room already exists in the list
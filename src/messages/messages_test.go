@@ -0,0 +1,120 @@
+package messages
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	Features "github.com/Icey-Glitch/Syncplay-G/features"
+	connM "github.com/Icey-Glitch/Syncplay-G/mngr/conn"
+	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+)
+
+// testConn is a net.Conn that records everything written to it
+type testConn struct {
+	written bytes.Buffer
+	mutex   sync.Mutex
+}
+
+func (c *testConn) Read(b []byte) (int, error) { return 0, net.ErrClosed }
+func (c *testConn) Write(b []byte) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.written.Write(b)
+}
+func (c *testConn) Close() error                       { return nil }
+func (c *testConn) LocalAddr() net.Addr                { return &net.TCPAddr{} }
+func (c *testConn) RemoteAddr() net.Addr               { return &net.TCPAddr{} }
+func (c *testConn) SetDeadline(t time.Time) error      { return nil }
+func (c *testConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *testConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// messages returns and forgets the JSON messages written so far
+func (c *testConn) messages(t *testing.T) []map[string]interface{} {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	result := make([]map[string]interface{}, 0)
+	scanner := bufio.NewScanner(&c.written)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		message := make(map[string]interface{})
+		assert.NoError(t, json.Unmarshal([]byte(line), &message))
+		result = append(result, message)
+	}
+	c.written.Reset()
+	return result
+}
+
+// chats returns the chat messages written so far
+func (c *testConn) chats(t *testing.T) []string {
+	chats := make([]string, 0)
+	for _, message := range c.messages(t) {
+		if chat, ok := message["Chat"].(map[string]interface{}); ok {
+			chats = append(chats, chat["message"].(string))
+		}
+	}
+	return chats
+}
+
+// lastState returns the playstate of the last State message written so far
+func lastState(t *testing.T, messages []map[string]interface{}) map[string]interface{} {
+	var playstate map[string]interface{}
+	for _, message := range messages {
+		if state, ok := message["State"].(map[string]interface{}); ok {
+			playstate = state["playstate"].(map[string]interface{})
+		}
+	}
+	return playstate
+}
+
+// newTestRoom creates a room with the default configuration
+func newTestRoom(t *testing.T) (*connM.ConnectionManager, *roomM.Room) {
+	Features.SetGlobalFeatures(*Features.NewFeatures())
+	Features.SetConfig(*Features.NewConfig())
+
+	cm := connM.NewConnectionManager()
+	return cm, cm.CreateRoom(t.Name())
+}
+
+// newGlobalTestRoom creates a room on the global connection manager, for handlers that look
+// rooms and users up there
+func newGlobalTestRoom(t *testing.T) (*connM.ConnectionManager, *roomM.Room) {
+	Features.SetGlobalFeatures(*Features.NewFeatures())
+	Features.SetConfig(*Features.NewConfig())
+
+	cm := connM.GetConnectionManager()
+	return cm, cm.CreateRoom(t.Name())
+}
+
+// joinTestRoom adds a user with a recording connection to the room
+func joinTestRoom(t *testing.T, cm *connM.ConnectionManager, room *roomM.Room, username string) (*roomM.Connection, *testConn) {
+	conn := &testConn{}
+	connection, err := cm.AddConnection(username, room.Name, nil, conn)
+	assert.NoError(t, err)
+	return connection, conn
+}
+
+// readyBroadcasts returns the Set.ready payloads among messages
+func readyBroadcasts(messages []map[string]interface{}) []map[string]interface{} {
+	broadcasts := make([]map[string]interface{}, 0)
+	for _, message := range messages {
+		set, ok := message["Set"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if ready, ok := set["ready"].(map[string]interface{}); ok {
+			broadcasts = append(broadcasts, ready)
+		}
+	}
+	return broadcasts
+}
@@ -0,0 +1,84 @@
+package messages
+
+import (
+	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
+)
+
+func init() {
+	registerChatCommand("op", "/op <user> - make a user a room operator (operators only)", handleOpCommand)
+	registerChatCommand("deop", "/deop <user> - revoke a user's operator rights (operators only)", handleDeopCommand)
+	registerChatCommand("mute", "/mute <user> - stop a user from chatting and sending private messages (operators only)", handleMuteCommand)
+	registerChatCommand("unmute", "/unmute <user> - let a muted user chat again (operators only)", handleUnmuteCommand)
+}
+
+// requireOperator sends a notice and returns false if the user is not a room operator
+func requireOperator(connection roomM.Connection) bool {
+	if connection.Owner == nil || !connection.Owner.IsOperator(connection.Username) {
+		sendServerNotice(connection, "Only room operators can use this command")
+		return false
+	}
+	return true
+}
+
+func handleOpCommand(connection roomM.Connection, args string) {
+	if !requireOperator(connection) {
+		return
+	}
+
+	room := connection.Owner
+	if args == "" || room.GetConnectionByUsername(args) == nil {
+		sendServerNotice(connection, "Usage: /op <user in this room>")
+		return
+	}
+
+	room.AddOperator(args)
+	SendServerChatMessage(room, args+" is now a room operator")
+}
+
+func handleDeopCommand(connection roomM.Connection, args string) {
+	if !requireOperator(connection) {
+		return
+	}
+
+	if args == "" {
+		sendServerNotice(connection, "Usage: /deop <user>")
+		return
+	}
+
+	connection.Owner.RemoveOperator(args)
+	SendServerChatMessage(connection.Owner, args+" is no longer a room operator")
+}
+
+func handleMuteCommand(connection roomM.Connection, args string) {
+	if !requireOperator(connection) {
+		return
+	}
+
+	room := connection.Owner
+	if args == "" || room.GetConnectionByUsername(args) == nil {
+		sendServerNotice(connection, "Usage: /mute <user in this room>")
+		return
+	}
+
+	if room.IsOperator(args) {
+		sendServerNotice(connection, "Room operators can't be muted, /deop them first")
+		return
+	}
+
+	room.MuteUser(args)
+	SendServerChatMessage(room, args+" has been muted")
+}
+
+func handleUnmuteCommand(connection roomM.Connection, args string) {
+	if !requireOperator(connection) {
+		return
+	}
+
+	if args == "" || !connection.Owner.IsMuted(args) {
+		sendServerNotice(connection, "Usage: /unmute <muted user>")
+		return
+	}
+
+	connection.Owner.UnmuteUser(args)
+	SendServerChatMessage(connection.Owner, args+" can chat again")
+}
@@ -0,0 +1,35 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuteCommands(t *testing.T) {
+	cm, room := newGlobalTestRoom(t)
+	alice, _ := joinTestRoom(t, cm, room, "alice")
+	bob, bobConn := joinTestRoom(t, cm, room, "bob")
+	t.Cleanup(func() {
+		cm.RemoveConnection(alice.Conn)
+		cm.RemoveConnection(bob.Conn)
+	})
+	assert.True(t, room.IsOperator(alice.Username))
+
+	// Test case 1: only operators can mute, and operators can't be muted
+	HandleChatCommand(*bob, "/mute alice")
+	assert.False(t, room.IsMuted(alice.Username))
+	HandleChatCommand(*alice, "/mute bob")
+	assert.True(t, room.IsMuted(bob.Username))
+
+	// Test case 2: muted users can't chat or send private messages
+	bobConn.messages(t)
+	HandleChatMessage(*bob, "hello")
+	assert.Equal(t, []string{"You are muted in this room"}, bobConn.chats(t))
+	assert.Error(t, SendDirectMessage(*bob, alice.Username, "hello"))
+
+	// Test case 3: unmuting lets them talk again
+	HandleChatCommand(*alice, "/unmute bob")
+	assert.False(t, room.IsMuted(bob.Username))
+	assert.NoError(t, SendDirectMessage(*bob, alice.Username, "hello"))
+}
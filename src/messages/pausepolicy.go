@@ -0,0 +1,178 @@
+package messages
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
+)
+
+func init() {
+	registerChatCommand("pausepolicy", "/pausepolicy leave|join on|off, /pausepolicy lag <seconds> - when the server pauses the room (operators only)", handlePausePolicyCommand)
+}
+
+// joinWait tracks the joiners a room was paused for
+type joinWait struct {
+	waiting map[string]bool
+	mutex   sync.Mutex
+}
+
+// joinWaits holds the joinWait of every room
+var joinWaits sync.Map
+
+func getJoinWait(room *roomM.Room) *joinWait {
+	wait, _ := joinWaits.LoadOrStore(room, &joinWait{waiting: make(map[string]bool)})
+	return wait.(*joinWait)
+}
+
+// pauseRoom pauses the room on behalf of the server and tells everyone but skip why
+func pauseRoom(room *roomM.Room, reason string, skip string) {
+	room.PlaylistManager.SetRoomPaused(true, serverUsername, float64(time.Now().UnixNano())/1e9)
+	broadcastRoomStateExcept(room, skip)
+	SendServerChatMessage(room, reason)
+}
+
+// pauseForJoiner pauses a playing room until a new user has loaded the file
+func pauseForJoiner(connection roomM.Connection) {
+	room := connection.Owner
+	if !room.GetSettings().PauseOnJoin || room.IsSpectator(connection.Username) || len(room.GetConnections()) < 2 {
+		return
+	}
+
+	// only wait for users who are still in the room
+	if room.GetConnectionByUsername(connection.Username) == nil {
+		return
+	}
+
+	wait := getJoinWait(room)
+	wait.mutex.Lock()
+	defer wait.mutex.Unlock()
+
+	paused := room.PlaylistManager.GetUserPauseState()
+	if paused && len(wait.waiting) == 0 {
+		// paused by someone else, the room is not ours to resume
+		return
+	}
+
+	wait.waiting[connection.Username] = true
+	if !paused {
+		// the joiner gets the state with its session information
+		pauseRoom(room, "Paused until "+connection.Username+" has loaded the file", connection.Username)
+	}
+}
+
+// joinerLoaded resumes the room once every joiner it was paused for has loaded the file
+func joinerLoaded(room *roomM.Room, username string) {
+	wait := getJoinWait(room)
+	wait.mutex.Lock()
+	defer wait.mutex.Unlock()
+
+	if !wait.waiting[username] {
+		return
+	}
+	delete(wait.waiting, username)
+	if len(wait.waiting) > 0 {
+		return
+	}
+
+	// someone else paused or unpaused in the meantime
+	now := float64(time.Now().UnixNano()) / 1e9
+	clock := room.PlaylistManager.GetClock(now)
+	if !clock.Paused || clock.SetBy != serverUsername {
+		return
+	}
+
+	if notReady := unpauseBlockedBy(room); len(notReady) > 0 {
+		SendServerChatMessage(room, "Everyone has loaded the file, staying paused until ready: "+strings.Join(notReady, ", "))
+		return
+	}
+
+	room.PlaylistManager.SetRoomPaused(false, serverUsername, now)
+	broadcastRoomState(room)
+	SendServerChatMessage(room, "Everyone has loaded the file, resuming playback")
+}
+
+// forgetJoiner stops waiting for a joiner without resuming the room
+func forgetJoiner(room *roomM.Room, username string) {
+	wait := getJoinWait(room)
+	wait.mutex.Lock()
+	defer wait.mutex.Unlock()
+
+	delete(wait.waiting, username)
+}
+
+// applyLeavePolicy pauses the room for the users that stay when someone leaves
+func applyLeavePolicy(connection roomM.Connection) {
+	room := connection.Owner
+	if len(room.GetConnections()) < 2 {
+		forgetJoiner(room, connection.Username)
+		return
+	}
+
+	if room.GetSettings().PauseOnLeave && !room.IsSpectator(connection.Username) && !room.PlaylistManager.GetUserPauseState() {
+		forgetJoiner(room, connection.Username)
+		pauseRoom(room, "Paused, "+connection.Username+" left the room", connection.Username)
+		return
+	}
+
+	// the room may have been waiting for this user only
+	joinerLoaded(room, connection.Username)
+}
+
+// checkLag pauses a playing room when a user falls too far behind
+func checkLag(room *roomM.Room, username string) {
+	limit := room.GetSettings().PauseOnLag
+	if limit <= 0 || room.IsSpectator(username) || room.PlaylistManager.GetUserPauseState() {
+		return
+	}
+
+	offset, ok := room.PlaylistManager.Offsets(float64(time.Now().UnixNano()) / 1e9)[username]
+	if !ok || offset > -limit {
+		return
+	}
+
+	pauseRoom(room, "Paused, "+username+" is "+describeOffset(offset), "")
+}
+
+func handlePausePolicyCommand(connection roomM.Connection, args string) {
+	if !requireOperator(connection) {
+		return
+	}
+
+	usage := "Usage: /pausepolicy leave|join on|off, /pausepolicy lag <seconds>"
+	fields := strings.Fields(args)
+	if len(fields) != 2 {
+		sendServerNotice(connection, usage)
+		return
+	}
+
+	settings := connection.Owner.GetSettings()
+	switch fields[0] {
+	case "leave", "join":
+		if fields[1] != "on" && fields[1] != "off" {
+			sendServerNotice(connection, usage)
+			return
+		}
+		if fields[0] == "leave" {
+			settings.PauseOnLeave = fields[1] == "on"
+		} else {
+			settings.PauseOnJoin = fields[1] == "on"
+		}
+	case "lag":
+		seconds, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || seconds < 0 {
+			sendServerNotice(connection, usage)
+			return
+		}
+		settings.PauseOnLag = seconds
+	default:
+		sendServerNotice(connection, usage)
+		return
+	}
+	connection.Owner.SetSettings(settings)
+
+	SendServerChatMessage(connection.Owner, fmt.Sprintf("Pause on %s: %s", fields[0], fields[1]))
+}
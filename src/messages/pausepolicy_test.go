@@ -0,0 +1,84 @@
+package messages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Icey-Glitch/Syncplay-G/mngr/playlists"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPauseOnJoin(t *testing.T) {
+	cm, room := newTestRoom(t)
+	settings := room.GetSettings()
+	settings.PauseOnJoin = true
+	room.SetSettings(settings)
+
+	alice, _ := joinTestRoom(t, cm, room, "alice")
+	bob, _ := joinTestRoom(t, cm, room, "bob")
+	room.PlaylistManager.SetRoomPaused(false, alice.Username, float64(time.Now().UnixNano())/1e9)
+
+	// Test case 1: a user leaving does not pause the room
+	HandleUserLeftMessage(*bob)
+	room.RemoveConnection(bob.Conn)
+	clock := room.PlaylistManager.GetClock(float64(time.Now().UnixNano()) / 1e9)
+	assert.False(t, clock.Paused)
+
+	// Test case 2: a joiner pauses the room
+	carol, _ := joinTestRoom(t, cm, room, "carol")
+	assert.NoError(t, BroadcastJoinAnnouncement(*carol))
+	clock = room.PlaylistManager.GetClock(float64(time.Now().UnixNano()) / 1e9)
+	assert.True(t, clock.Paused)
+	assert.Equal(t, serverUsername, clock.SetBy)
+
+	// Test case 3: the room resumes once the joiner has loaded the file
+	joinerLoaded(room, carol.Username)
+	assert.False(t, room.PlaylistManager.GetClock(float64(time.Now().UnixNano())/1e9).Paused)
+}
+
+func TestPauseOnJoinStrictReadiness(t *testing.T) {
+	cm, room := newTestRoom(t)
+	settings := room.GetSettings()
+	settings.PauseOnJoin = true
+	settings.StrictReadiness = true
+	room.SetSettings(settings)
+
+	alice, aliceConn := joinTestRoom(t, cm, room, "alice")
+	room.SetUserReadyState(alice.Username, true, true)
+	room.PlaylistManager.SetRoomPaused(false, alice.Username, float64(time.Now().UnixNano())/1e9)
+
+	bob, _ := joinTestRoom(t, cm, room, "bob")
+	assert.NoError(t, BroadcastJoinAnnouncement(*bob))
+	aliceConn.messages(t)
+
+	// Test case 1: a joiner who is not ready keeps the room paused after loading
+	joinerLoaded(room, bob.Username)
+	assert.True(t, room.PlaylistManager.GetClock(float64(time.Now().UnixNano())/1e9).Paused)
+	assert.Contains(t, aliceConn.chats(t), "Everyone has loaded the file, staying paused until ready: bob")
+}
+
+func TestPauseOnLag(t *testing.T) {
+	cm, room := newTestRoom(t)
+	settings := room.GetSettings()
+	settings.PauseOnLag = 2
+	room.SetSettings(settings)
+
+	alice, _ := joinTestRoom(t, cm, room, "alice")
+	bob, _ := joinTestRoom(t, cm, room, "bob")
+	for _, username := range []string{"alice", "bob"} {
+		assert.NoError(t, room.PlaylistManager.SetUserFile(username, playlists.File{Name: "a.mkv", Duration: 600}))
+	}
+
+	now := float64(time.Now().UnixNano()) / 1e9
+	assert.NoError(t, UpdateGlobalState(*alice, 100.0, false, false, "alice", now, now, 1))
+
+	// Test case 1: a user within the limit keeps the room playing
+	assert.NoError(t, UpdateGlobalState(*bob, 99.0, false, false, "bob", now, now, 0))
+	assert.False(t, room.PlaylistManager.GetClock(now).Paused)
+
+	// Test case 2: a user falling behind pauses the room
+	assert.NoError(t, UpdateGlobalState(*bob, 95.0, false, false, "bob", now, now, 0))
+	clock := room.PlaylistManager.GetClock(now)
+	assert.True(t, clock.Paused)
+	assert.Equal(t, serverUsername, clock.SetBy)
+}
@@ -2,7 +2,11 @@ package messages
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 
+	Features "github.com/Icey-Glitch/Syncplay-G/features"
 	"github.com/Icey-Glitch/Syncplay-G/mngr/playlists"
 	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
 	"github.com/Icey-Glitch/Syncplay-G/utils"
@@ -12,9 +16,10 @@ type SetMessage struct {
 	User           *UserMessage                 `json:"user,omitempty"`
 	Ready          *ClientReadyMessage          `json:"ready,omitempty"`
 	PlaylistChange *ClientPlaylistChangeMessage `json:"playlistChange,omitempty"`
-	PlaylistIndex  *PlaylistIndexMessage        `json:"playlistIndex,omitempty"`
-	File           *FileMessage                 `json:"file,omitempty"`
+	PlaylistIndex  *ClientPlaylistIndexMessage  `json:"playlistIndex,omitempty"`
+	File           *ClientFileMessage           `json:"file,omitempty"`
 	Room           *RoomMessage                 `json:"room,omitempty"`
+	Bookmark       *ClientBookmarkMessage       `json:"bookmark,omitempty"`
 }
 
 type PlaylistChangeMessage struct {
@@ -40,41 +45,44 @@ type PlaylistIndexMessage struct {
 }
 
 type ClientPlaylistIndexMessage struct {
-	Index int `json:"index"`
+	Index *int `json:"index"`
 }
 
 // HandlePlaylistIndexMessage handle
-func HandlePlaylistIndexMessage(connection roomM.Connection, msg *PlaylistIndexMessage) {
+func HandlePlaylistIndexMessage(connection roomM.Connection, msg *ClientPlaylistIndexMessage) {
+	// client {"Set": {"playlistIndex": {"index": 1}}}
 
 	room := connection.Owner
 	if room == nil {
 		return
 	}
 
-	playlistObject := room.PlaylistManager.GetPlaylist()
-
-	if playlistObject.User.Username != "" {
-		// check if the user is the same as the one who sent the message
-		if playlistObject.User.Username != connection.Username {
-			return
-		}
+	if !Features.GlobalFeatures.SharedPlaylists {
+		handleUserPlaylistIndex(connection, msg)
+		return
 	}
 
-	index := msg.Set.PlaylistIndex.Index
-
-	if index != nil {
-		playlistObject.Index = index.(float64)
-	} else {
-		playlistObject.Index = 0
+	if rejectSpectatorPlaylist(connection) {
+		return
 	}
 
-	playlistObject.User.Username = connection.Username
-
-	room.PlaylistManager.SetPlaylist(playlistObject)
+	previousIndex, hadIndex := room.PlaylistManager.GetIndex()
 
-	if playlistObject.User.Username != connection.Username {
+	// the stored playlist decides which indexes are valid
+	if msg.Index == nil {
+		sendPlaylistIndexToUser(connection)
+		return
+	}
+	if err := room.PlaylistManager.SetIndex(*msg.Index, connection.Username); err != nil {
+		fmt.Println("Error: rejected playlist index from", connection.Username, ":", err)
+		sendPlaylistIndexToUser(connection)
 		return
 	}
+
+	if hadIndex && previousIndex != *msg.Index && room.GetSettings().ResetReadyOnIndexChange {
+		resetReadiness(room, roomUsernames(room), "the playlist moved to another file")
+	}
+
 	SendPlaylistIndexMessage(connection)
 }
 
@@ -87,7 +95,68 @@ func HandlePlaylistChangeMessage(msg *ClientPlaylistChangeMessage, connection ro
 		return
 	}
 
-	SendPlaylistChangeMessage(connection, msg.Files)
+	if rejectSpectatorPlaylist(connection) {
+		return
+	}
+
+	files, rejected := room.GetSettings().TrustRules.Filter(msg.Files)
+	if len(rejected) > 0 {
+		notifyRejectedEntries(connection, rejected)
+	}
+	msg = &ClientPlaylistChangeMessage{Files: files}
+
+	if !Features.GlobalFeatures.SharedPlaylists {
+		handleUserPlaylistChange(connection, msg)
+		return
+	}
+
+	// merged with edits others made since this user's last update
+	room.PlaylistManager.ApplyFileList(msg.Files, connection.Username)
+	SendPlaylistChangeMessage(connection, room.PlaylistManager.GetFileNames())
+}
+
+// SendPlaylistToUser sends the stored playlist and index to a single user, used for new joiners
+func SendPlaylistToUser(connection roomM.Connection) {
+	if connection.Owner == nil {
+		return
+	}
+
+	if !Features.GlobalFeatures.SharedPlaylists {
+		sendUserPlaylist(connection)
+		return
+	}
+
+	playlist := connection.Owner.PlaylistManager.GetPlaylist()
+
+	playlistChangeMessage := PlaylistChangeMessage{}
+	playlistChangeMessage.Set.PlaylistChange.User = nil
+	playlistChangeMessage.Set.PlaylistChange.Files = connection.Owner.PlaylistManager.GetFileNames()
+	if playlist.User.Username != "" {
+		playlistChangeMessage.Set.PlaylistChange.User = playlist.User.Username
+	}
+
+	err := utils.SendJSONMessage(connection.Conn, playlistChangeMessage)
+	if err != nil {
+		fmt.Println("Error sending playlist to", connection.Username, ":", err)
+		return
+	}
+	connection.Owner.PlaylistManager.MarkSeen(connection.Username)
+
+	sendPlaylistIndexToUser(connection)
+}
+
+// sendPlaylistIndexToUser sends the stored playlist index to a single user
+func sendPlaylistIndexToUser(connection roomM.Connection) {
+	playlist := connection.Owner.PlaylistManager.GetPlaylist()
+
+	playlistIndexMessage := PlaylistIndexMessage{}
+	playlistIndexMessage.Set.PlaylistIndex.Index = playlist.Index
+	playlistIndexMessage.Set.PlaylistIndex.User = playlist.User.Username
+
+	err := utils.SendJSONMessage(connection.Conn, playlistIndexMessage)
+	if err != nil {
+		fmt.Println("Error sending playlist index to", connection.Username, ":", err)
+	}
 }
 
 // ExtractStatePlaystateArguments extract
@@ -153,6 +222,16 @@ func SendPlaylistChangeMessage(connection roomM.Connection, files []string) {
 	}
 
 	utils.SendJSONMessageMultiCast(playlistChangeMessage, connection.Owner)
+
+	for _, user := range connection.Owner.GetConnections() {
+		connection.Owner.PlaylistManager.MarkSeen(user.Username)
+	}
+}
+
+type ClientFileMessage struct {
+	Duration float64     `json:"duration"`
+	Name     string      `json:"name"`
+	Size     interface{} `json:"size"`
 }
 
 type FileMessage struct {
@@ -165,7 +244,7 @@ type FileMessage struct {
 	} `json:"Set"`
 }
 
-func HandleFileMessage(connection roomM.Connection, msg *FileMessage) {
+func HandleFileMessage(connection roomM.Connection, msg *ClientFileMessage) {
 	// Client >> {"Set": {"file": {"duration": 596.458, "name": "BigBuckBunny.avi", "size": 220514438}}}
 	// Server (to all who can see room) << {"Set": {"user": {"Bob": {"room": {"name": "SyncRoom"}, "file": {"duration": 596.458, "name": "BigBuckBunny.avi", "size": "220514438"}}}}}
 
@@ -181,9 +260,9 @@ func HandleFileMessage(connection roomM.Connection, msg *FileMessage) {
 	// desern communication type: raw, hashed, or not sent
 
 	// extract the file data
-	duration := msg.Set.File.Duration
-	name := msg.Set.File.Name
-	size := msg.Set.File.Size
+	duration := msg.Duration
+	name := msg.Name
+	size := msg.Size
 
 	// check if the file data is valid
 	// if duration < 0 || name == "" || size < 0 {
@@ -194,7 +273,7 @@ func HandleFileMessage(connection roomM.Connection, msg *FileMessage) {
 	// check if size is sent hashed (not float64)
 	var fileObj playlists.File
 	var err error
-	switch msg.Set.File.Size.(type) {
+	switch size.(type) {
 	case float64:
 		fileObj, err = room.PlaylistManager.AddFile(duration, name, size.(float64), connection.Username, "")
 		if err != nil {
@@ -207,21 +286,29 @@ func HandleFileMessage(connection roomM.Connection, msg *FileMessage) {
 			fmt.Println("Error: failed to add file to playlist")
 			return
 		}
-	default:
-		fileObj, err = room.PlaylistManager.AddFile(duration, name, 0, connection.Username, msg.Set.File.Size.(string))
+	case string:
+		fileObj, err = room.PlaylistManager.AddFile(duration, name, 0, connection.Username, size.(string))
 		if err != nil {
 			fmt.Println("Error: failed to add file to playlist")
 			return
 		}
-
+	default:
+		fmt.Println("Error: invalid file size")
+		return
 	}
 
+	previous, _ := room.PlaylistManager.GetUserObject(connection.Username)
+
 	err = room.PlaylistManager.SetUserFile(connection.Username, fileObj)
 	if err != nil {
 		fmt.Println("Error: failed to set user file")
 		return
 	}
 
+	if previous.File != nil && previous.File.Name != name && room.GetSettings().ResetReadyOnFileChange {
+		resetReadiness(room, []string{connection.Username}, connection.Username+" opened a different file")
+	}
+
 	// create the file message
 	fileMessage := FileMessage{}
 
@@ -232,4 +319,45 @@ func HandleFileMessage(connection roomM.Connection, msg *FileMessage) {
 	// send the file message to all connections in the room
 	utils.SendJSONMessageMultiCast(fileMessage, connection.Owner)
 
+	notifyFileMismatches(room)
+	joinerLoaded(room, connection.Username)
+}
+
+// announcedMismatches holds the file mismatch description last announced in every room
+var announcedMismatches sync.Map
+
+// notifyFileMismatches tells the room which users have a different file open than the rest, and
+// when everyone has the same file again. Nothing is sent while the mismatches stay the same.
+func notifyFileMismatches(room *roomM.Room) {
+	description := describeFileMismatches(room.PlaylistManager.FileMismatches())
+
+	previous, _ := announcedMismatches.Load(room)
+	if previous == nil {
+		previous = ""
+	}
+	if description == previous {
+		return
+	}
+	announcedMismatches.Store(room, description)
+
+	if description == "" {
+		SendServerChatMessage(room, "Everyone has the same file open again")
+		return
+	}
+	SendServerChatMessage(room, "File mismatch: "+description+" differ from the rest of the room")
+}
+
+// describeFileMismatches lists the mismatching users and what differs, empty when nothing does
+func describeFileMismatches(mismatches map[string]playlists.FileDifference) string {
+	usernames := make([]string, 0, len(mismatches))
+	for username := range mismatches {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+
+	parts := make([]string, 0, len(usernames))
+	for _, username := range usernames {
+		parts = append(parts, fmt.Sprintf("%s (%s)", username, strings.Join(mismatches[username].Fields(), ", ")))
+	}
+	return strings.Join(parts, "; ")
 }
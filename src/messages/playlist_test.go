@@ -0,0 +1,43 @@
+package messages
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mismatchNotices returns the file mismatch notices among chats
+func mismatchNotices(chats []string) []string {
+	notices := make([]string, 0)
+	for _, chat := range chats {
+		if strings.HasPrefix(chat, "File mismatch") || strings.HasPrefix(chat, "Everyone has the same file") {
+			notices = append(notices, chat)
+		}
+	}
+	return notices
+}
+
+func TestFileMismatchNotices(t *testing.T) {
+	cm, room := newTestRoom(t)
+	alice, aliceConn := joinTestRoom(t, cm, room, "alice")
+	bob, _ := joinTestRoom(t, cm, room, "bob")
+	carol, _ := joinTestRoom(t, cm, room, "carol")
+
+	HandleFileMessage(*alice, &ClientFileMessage{Name: "a.mkv", Duration: 600, Size: 1000.0})
+	HandleFileMessage(*bob, &ClientFileMessage{Name: "a.mkv", Duration: 600, Size: 1000.0})
+	assert.Empty(t, mismatchNotices(aliceConn.chats(t)))
+
+	// Test case 1: a mismatch is announced once
+	HandleFileMessage(*carol, &ClientFileMessage{Name: "b.mkv", Duration: 600, Size: 1000.0})
+	assert.Equal(t, []string{"File mismatch: carol (name) differ from the rest of the room"}, mismatchNotices(aliceConn.chats(t)))
+
+	// Test case 2: reloading the same files announces nothing
+	HandleFileMessage(*carol, &ClientFileMessage{Name: "b.mkv", Duration: 600, Size: 1000.0})
+	HandleFileMessage(*alice, &ClientFileMessage{Name: "a.mkv", Duration: 600, Size: 1000.0})
+	assert.Empty(t, mismatchNotices(aliceConn.chats(t)))
+
+	// Test case 3: the room is told when the mismatch clears
+	HandleFileMessage(*carol, &ClientFileMessage{Name: "a.mkv", Duration: 600, Size: 1000.0})
+	assert.Equal(t, []string{"Everyone has the same file open again"}, mismatchNotices(aliceConn.chats(t)))
+}
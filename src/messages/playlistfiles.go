@@ -0,0 +1,80 @@
+package messages
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	Features "github.com/Icey-Glitch/Syncplay-G/features"
+	"github.com/Icey-Glitch/Syncplay-G/mngr/playlists"
+	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
+)
+
+func init() {
+	registerChatCommand("import", "/import <file.m3u|file.json> - load the playlist from the server playlist directory (operators only)", handleImportCommand)
+	registerChatCommand("export", "/export <file.m3u|file.json> [overwrite] - save the playlist to the server playlist directory (operators only)", handleExportCommand)
+}
+
+// playlistPath resolves a file name inside the configured playlist directory
+func playlistPath(name string) (string, error) {
+	dir := Features.GetConfig().PlaylistDir
+	if dir == "" {
+		return "", fmt.Errorf("playlist import and export are disabled on this server")
+	}
+	if name == "" {
+		return "", fmt.Errorf("no playlist file given")
+	}
+
+	// cleaning against the root keeps the path inside dir
+	return filepath.Join(dir, filepath.Clean("/"+name)), nil
+}
+
+func handleImportCommand(connection roomM.Connection, args string) {
+	if !requireOperator(connection) {
+		return
+	}
+
+	path, err := playlistPath(args)
+	if err != nil {
+		sendServerNotice(connection, err.Error())
+		return
+	}
+
+	rejected, err := connection.Owner.PlaylistManager.ImportPlaylist(path, connection.Username, connection.Owner.GetSettings().TrustRules)
+	if err != nil {
+		sendServerNotice(connection, "Import failed: "+err.Error())
+		return
+	}
+	if len(rejected) > 0 {
+		notifyRejectedEntries(connection, rejected)
+	}
+
+	SendPlaylistChangeMessage(connection, connection.Owner.PlaylistManager.GetFileNames())
+	SendServerChatMessage(connection.Owner, connection.Username+" imported the playlist "+args)
+}
+
+func handleExportCommand(connection roomM.Connection, args string) {
+	if !requireOperator(connection) {
+		return
+	}
+
+	name, overwrite := strings.CutSuffix(strings.TrimSpace(args), " overwrite")
+
+	path, err := playlistPath(name)
+	if err != nil {
+		sendServerNotice(connection, err.Error())
+		return
+	}
+
+	if err = connection.Owner.PlaylistManager.ExportPlaylist(path, overwrite); err != nil {
+		if errors.Is(err, playlists.ErrPlaylistExists) {
+			sendServerNotice(connection, "Export failed: "+err.Error()+", use /export "+name+" overwrite to replace it")
+			return
+		}
+		sendServerNotice(connection, "Export failed: "+err.Error())
+		return
+	}
+
+	sendServerNotice(connection, "Playlist exported to "+name)
+}
@@ -0,0 +1,73 @@
+package messages
+
+import (
+	"sync"
+
+	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
+)
+
+var (
+	// roomsWithPolicies holds the stop channel of every room whose policy watchers are running
+	roomsWithPolicies = make(map[*roomM.Room]chan struct{})
+	policiesMutex     sync.Mutex
+)
+
+// SetupRoomPolicies starts the server side policy watchers of a room. It is safe to call more than
+// once, the watchers are only started the first time and run until the room is empty again.
+// Call it after the user has been added to the room.
+func SetupRoomPolicies(room *roomM.Room) {
+	if room == nil {
+		return
+	}
+
+	policiesMutex.Lock()
+	defer policiesMutex.Unlock()
+
+	if _, running := roomsWithPolicies[room]; running {
+		return
+	}
+
+	stop := make(chan struct{})
+	roomsWithPolicies[room] = stop
+
+	startAutoPlay(room, stop)
+	startStatePush(room, stop)
+
+	empty := room.SubscribeToEmpty()
+	go func() {
+		for range empty {
+			if stopRoomPolicies(room) {
+				room.UnsubscribeFromEmpty(empty)
+				return
+			}
+		}
+	}()
+}
+
+// stopRoomPolicies stops the policy watchers of a room and forgets its policy state, unless
+// someone joined again in the meantime
+func stopRoomPolicies(room *roomM.Room) bool {
+	policiesMutex.Lock()
+	defer policiesMutex.Unlock()
+
+	if len(room.GetConnections()) > 0 {
+		return false
+	}
+
+	if stop, running := roomsWithPolicies[room]; running {
+		close(stop)
+		delete(roomsWithPolicies, room)
+	}
+	joinWaits.Delete(room)
+	announcedMismatches.Delete(room)
+	return true
+}
+
+// policiesRunning reports whether the policy watchers of a room are running
+func policiesRunning(room *roomM.Room) bool {
+	policiesMutex.Lock()
+	defer policiesMutex.Unlock()
+
+	_, running := roomsWithPolicies[room]
+	return running
+}
@@ -0,0 +1,28 @@
+package messages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoomPoliciesStopWhenEmpty(t *testing.T) {
+	cm, room := newTestRoom(t)
+
+	alice, _ := joinTestRoom(t, cm, room, "alice")
+	SetupRoomPolicies(room)
+	assert.True(t, policiesRunning(room))
+	getJoinWait(room)
+
+	// Test case 1: the watchers stop and the room's policy state is forgotten once the last user left
+	room.RemoveConnection(alice.Conn)
+	assert.Eventually(t, func() bool { return !policiesRunning(room) }, time.Second, 10*time.Millisecond)
+	_, waiting := joinWaits.Load(room)
+	assert.False(t, waiting)
+
+	// Test case 2: they start again with the next join
+	joinTestRoom(t, cm, room, "bob")
+	SetupRoomPolicies(room)
+	assert.True(t, policiesRunning(room))
+}
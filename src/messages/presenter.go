@@ -0,0 +1,59 @@
+package messages
+
+import (
+	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
+)
+
+func init() {
+	registerChatCommand("presenter", "/presenter [<user>|off] - let one user drive playback for everyone (operators only)", handlePresenterCommand)
+}
+
+// announcePresenterLeft ends presenter mode when the presenter leaves the room
+func announcePresenterLeft(connection roomM.Connection) {
+	if connection.Owner.GetPresenter() != connection.Username {
+		return
+	}
+
+	connection.Owner.SetPresenter("")
+	SendServerChatMessage(connection.Owner, "Presenter mode ended, "+connection.Username+" left the room")
+}
+
+func handlePresenterCommand(connection roomM.Connection, args string) {
+	room := connection.Owner
+	if args == "" {
+		if presenter := room.GetPresenter(); presenter != "" {
+			sendServerNotice(connection, "Playback follows "+presenter)
+		} else {
+			sendServerNotice(connection, "Presenter mode is off")
+		}
+		return
+	}
+
+	if !requireOperator(connection) {
+		return
+	}
+
+	previous := room.GetPresenter()
+
+	if args == "off" {
+		if previous == "" {
+			sendServerNotice(connection, "Presenter mode is already off")
+			return
+		}
+		room.SetPresenter("")
+		SendServerChatMessage(room, "Presenter mode ended, everyone controls playback again")
+		return
+	}
+
+	if room.GetConnectionByUsername(args) == nil {
+		sendServerNotice(connection, "Usage: /presenter <user in this room>|off")
+		return
+	}
+
+	room.SetPresenter(args)
+	if previous != "" && previous != args {
+		SendServerChatMessage(room, previous+" handed the presentation to "+args+", playback follows them")
+	} else {
+		SendServerChatMessage(room, args+" is now the presenter, playback follows them")
+	}
+}
@@ -0,0 +1,38 @@
+package messages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Icey-Glitch/Syncplay-G/mngr/playlists"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresenterDriftMovesRoom(t *testing.T) {
+	cm, room := newTestRoom(t)
+	alice, aliceConn := joinTestRoom(t, cm, room, "alice")
+	bob, bobConn := joinTestRoom(t, cm, room, "bob")
+	for _, username := range []string{"alice", "bob"} {
+		assert.NoError(t, room.PlaylistManager.SetUserFile(username, playlists.File{Name: "a.mkv", Duration: 600}))
+	}
+	room.SetPresenter(alice.Username)
+
+	now := float64(time.Now().UnixNano()) / 1e9
+	assert.NoError(t, UpdateGlobalState(*alice, 100.0, false, false, "alice", now, now, 1))
+	aliceConn.messages(t)
+	bobConn.messages(t)
+
+	// Test case 1: the presenter falling behind moves the room clock back to them
+	assert.NoError(t, UpdateGlobalState(*alice, 95.0, false, false, "alice", now, now, 0))
+	assert.InDelta(t, 95, room.PlaylistManager.GetClock(now).Position, 1e-9)
+
+	// Test case 2: the other user is sent the presenter's position right away
+	playstate := lastState(t, bobConn.messages(t))
+	assert.NotNil(t, playstate)
+	assert.InDelta(t, 95, playstate["position"].(float64), 0.5)
+	assert.Nil(t, lastState(t, aliceConn.messages(t)))
+
+	// Test case 3: the follower's own report does not move the clock
+	assert.NoError(t, UpdateGlobalState(*bob, 100.0, false, false, "bob", now, now, 0))
+	assert.InDelta(t, 95, room.PlaylistManager.GetClock(now).Position, 1e-9)
+}
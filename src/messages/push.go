@@ -0,0 +1,68 @@
+package messages
+
+import (
+	"time"
+
+	"github.com/Icey-Glitch/Syncplay-G/mngr/playlists"
+	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
+)
+
+// pushResolution is how often a room checks whether a keepalive state is due
+const pushResolution = 250 * time.Millisecond
+
+// keepalive intervals in seconds, paused rooms stay well below the client's protocol timeout
+const (
+	keepaliveDesynced = 0.5
+	keepalivePlaying  = 1.0
+	keepalivePaused   = 4.0
+)
+
+// startStatePush sends keepalive states to the users of a room. Changes are pushed to everyone
+// as they happen by broadcastRoomState, this only keeps idle clients and their RTT up to date.
+// It runs until stop is closed.
+func startStatePush(room *roomM.Room, stop chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(pushResolution)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				pushKeepalives(room)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// pushKeepalives sends a state to every user whose keepalive interval has passed
+func pushKeepalives(room *roomM.Room) {
+	now := float64(time.Now().UnixNano()) / 1e9
+	clock := room.PlaylistManager.GetClock(now)
+
+	for _, connection := range room.GetConnections() {
+		if connection.Conn == nil {
+			continue
+		}
+
+		user, exists := room.PlaylistManager.GetUserObject(connection.Username)
+		if !exists || now-user.LastMessageAge < keepaliveInterval(clock, user) {
+			continue
+		}
+
+		SendUserState(*connection)
+	}
+}
+
+// keepaliveInterval picks how often a user is sent the room state when nothing changes
+func keepaliveInterval(clock playlists.Clock, user playlists.User) float64 {
+	switch {
+	case user.Desynced || clock.State == playlists.ClockSeeking:
+		return keepaliveDesynced
+	case clock.Paused:
+		return keepalivePaused
+	default:
+		return keepalivePlaying
+	}
+}
@@ -0,0 +1,63 @@
+package messages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Icey-Glitch/Syncplay-G/mngr/playlists"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeepaliveInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		clock    playlists.Clock
+		user     playlists.User
+		expected float64
+	}{
+		{"playing", playlists.Clock{State: playlists.ClockPlaying}, playlists.User{}, keepalivePlaying},
+		{"paused", playlists.Clock{State: playlists.ClockPaused, Paused: true}, playlists.User{}, keepalivePaused},
+		{"seeking", playlists.Clock{State: playlists.ClockSeeking}, playlists.User{}, keepaliveDesynced},
+		{"desynced while playing", playlists.Clock{State: playlists.ClockPlaying}, playlists.User{Desynced: true}, keepaliveDesynced},
+		{"desynced while paused", playlists.Clock{State: playlists.ClockPaused, Paused: true}, playlists.User{Desynced: true}, keepaliveDesynced},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, keepaliveInterval(test.clock, test.user))
+		})
+	}
+
+	assert.Equal(t, 0.5, keepaliveDesynced)
+	assert.Equal(t, 1.0, keepalivePlaying)
+	assert.Equal(t, 4.0, keepalivePaused)
+}
+
+func TestPushKeepalives(t *testing.T) {
+	cm, room := newTestRoom(t)
+	alice, _ := joinTestRoom(t, cm, room, "alice")
+	bob, bobConn := joinTestRoom(t, cm, room, "bob")
+
+	now := float64(time.Now().UnixNano()) / 1e9
+	room.PlaylistManager.SetRoomPaused(false, alice.Username, now)
+
+	// Test case 1: nobody is sent a keepalive before their interval passed
+	room.PlaylistManager.SetLastMessageAge(alice.Username, now)
+	room.PlaylistManager.SetLastMessageAge(bob.Username, now)
+	pushKeepalives(room)
+	assert.Nil(t, lastState(t, bobConn.messages(t)))
+
+	// Test case 2: a playing room sends one once a second has passed
+	room.PlaylistManager.SetLastMessageAge(bob.Username, now-keepalivePlaying)
+	pushKeepalives(room)
+	assert.NotNil(t, lastState(t, bobConn.messages(t)))
+
+	// Test case 3: a state change reaches the others right away, without waiting for the interval
+	room.PlaylistManager.SetLastMessageAge(bob.Username, float64(time.Now().UnixNano())/1e9)
+	assert.NoError(t, UpdateGlobalState(*alice, 10.0, true, false, "alice", now, now, 1))
+	state := lastState(t, bobConn.messages(t))
+	if assert.NotNil(t, state) {
+		assert.Equal(t, true, state["paused"])
+		assert.Equal(t, "alice", state["setBy"])
+	}
+}
@@ -3,6 +3,7 @@ package messages
 import (
 	"fmt"
 
+	Features "github.com/Icey-Glitch/Syncplay-G/features"
 	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
 	"github.com/Icey-Glitch/Syncplay-G/utils"
 )
@@ -13,6 +14,7 @@ type ReadyMessage struct {
 			Username          string `json:"username"`
 			IsReady           bool   `json:"isReady"`
 			ManuallyInitiated bool   `json:"manuallyInitiated"`
+			SetBy             string `json:"setBy,omitempty"`
 		} `json:"ready"`
 	} `json:"Set"`
 }
@@ -20,6 +22,9 @@ type ReadyMessage struct {
 type ClientReadyMessage struct {
 	IsReady           bool `json:"isReady"`
 	ManuallyInitiated bool `json:"manuallyInitiated"`
+
+	// set when changing another user's readiness (setOthersReadiness)
+	Username string `json:"username,omitempty"`
 }
 
 func SendReadyMessageInit(connection roomM.Connection) {
@@ -44,9 +49,53 @@ func HandleReadyMessage(msg *ClientReadyMessage, usr *roomM.Connection) {
 		fmt.Println("Error: Connection not found for ready message")
 		return
 	}
+
+	if msg.Username != "" && msg.Username != usr.Username {
+		setOthersReadiness(*msg, *usr)
+		return
+	}
+	if rejectSpectator(*usr, "their readiness") {
+		return
+	}
 	readyMessage(*msg, *usr)
 }
 
+// setOthersReadiness lets a room operator change the readiness of another user
+func setOthersReadiness(msg ClientReadyMessage, connection roomM.Connection) {
+	// {"Set": {"ready": {"username": "Bob", "isReady": true, "manuallyInitiated": true}}}
+	room := connection.Owner
+	if room == nil {
+		fmt.Println("Error: Room not found for connection")
+		return
+	}
+
+	if !Features.GlobalFeatures.SetOthersReadiness || !room.IsOperator(connection.Username) {
+		sendServerNotice(connection, "Only room operators can change other users' readiness")
+		return
+	}
+
+	if room.GetConnectionByUsername(msg.Username) == nil {
+		sendServerNotice(connection, "User "+msg.Username+" is not in this room")
+		return
+	}
+
+	if room.IsSpectator(msg.Username) {
+		sendServerNotice(connection, msg.Username+" is a spectator and does not take part in readiness")
+		return
+	}
+
+	room.SetUserReadyState(msg.Username, msg.IsReady, msg.ManuallyInitiated)
+
+	// {"Set": {"ready": {"username": "Bob", "isReady": true, "manuallyInitiated": true, "setBy": "Alice"}}}
+	readyMessage := ReadyMessage{}
+	readyMessage.Set.Ready.Username = msg.Username
+	readyMessage.Set.Ready.IsReady = msg.IsReady
+	readyMessage.Set.Ready.ManuallyInitiated = msg.ManuallyInitiated
+	readyMessage.Set.Ready.SetBy = connection.Username
+
+	utils.SendJSONMessageMultiCast(readyMessage, room)
+}
+
 func readyMessage(msg ClientReadyMessage, connection roomM.Connection) {
 
 	// extract the data from the map
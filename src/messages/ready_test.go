@@ -0,0 +1,44 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetOthersReadiness(t *testing.T) {
+	cm, room := newTestRoom(t)
+	alice, aliceConn := joinTestRoom(t, cm, room, "alice")
+	bob, bobConn := joinTestRoom(t, cm, room, "bob")
+	carol, carolConn := joinTestRoom(t, cm, room, "carol")
+	assert.True(t, room.IsOperator(alice.Username))
+	isReady := func(username string) bool {
+		state, _ := room.ReadyManager.GetUserReadyState(username)
+		return state.IsReady
+	}
+
+	// Test case 1: an operator sets another user's readiness and the room is told who did it
+	HandleReadyMessage(&ClientReadyMessage{Username: "bob", IsReady: true, ManuallyInitiated: true}, alice)
+	assert.True(t, isReady(bob.Username))
+	broadcasts := readyBroadcasts(carolConn.messages(t))
+	if assert.Len(t, broadcasts, 1) {
+		assert.Equal(t, "bob", broadcasts[0]["username"])
+		assert.Equal(t, true, broadcasts[0]["isReady"])
+		assert.Equal(t, "alice", broadcasts[0]["setBy"])
+	}
+	bobConn.messages(t)
+	aliceConn.messages(t)
+
+	// Test case 2: other users can't
+	HandleReadyMessage(&ClientReadyMessage{Username: "carol", IsReady: true, ManuallyInitiated: true}, bob)
+	assert.False(t, isReady(carol.Username))
+	assert.Empty(t, readyBroadcasts(carolConn.messages(t)))
+	assert.Equal(t, []string{"Only room operators can change other users' readiness"}, bobConn.chats(t))
+
+	// Test case 3: users who are not in the room are refused
+	HandleReadyMessage(&ClientReadyMessage{Username: "dave", IsReady: true, ManuallyInitiated: true}, alice)
+	_, exists := room.ReadyManager.GetUserReadyState("dave")
+	assert.False(t, exists)
+	assert.Empty(t, readyBroadcasts(carolConn.messages(t)))
+	assert.Equal(t, []string{"User dave is not in this room"}, aliceConn.chats(t))
+}
@@ -0,0 +1,115 @@
+package messages
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
+	"github.com/Icey-Glitch/Syncplay-G/utils"
+)
+
+func init() {
+	registerChatCommand("readyreset", "/readyreset index|file|seek on|off [seconds] - reset readiness when the room moves on (operators only)", handleReadyResetCommand)
+}
+
+// resetReadiness sets the given users that are ready to not ready and broadcasts the change
+func resetReadiness(room *roomM.Room, usernames []string, reason string) {
+	reset := make([]string, 0, len(usernames))
+	for _, username := range usernames {
+		state, exists := room.ReadyManager.GetUserReadyState(username)
+		if !exists || !state.IsReady {
+			continue
+		}
+
+		room.SetUserReadyState(username, false, false)
+
+		readyMessage := ReadyMessage{}
+		readyMessage.Set.Ready.Username = username
+		readyMessage.Set.Ready.IsReady = false
+		readyMessage.Set.Ready.ManuallyInitiated = false
+
+		utils.SendJSONMessageMultiCast(readyMessage, room)
+		reset = append(reset, username)
+	}
+
+	if len(reset) > 0 {
+		SendServerChatMessage(room, "Readiness reset for "+strings.Join(reset, ", ")+": "+reason)
+	}
+}
+
+// resetReadinessOnSeek resets everyone but the seeker after a seek further than the room threshold
+func resetReadinessOnSeek(connection roomM.Connection, position float64, doSeek bool) {
+	room := connection.Owner
+	if !doSeek || room == nil {
+		return
+	}
+
+	settings := room.GetSettings()
+	if !settings.ResetReadyOnSeek {
+		return
+	}
+
+	current, _ := room.PlaylistManager.CalculatePosition(float64(time.Now().UnixNano()) / 1e9)
+	if math.Abs(position-current) < settings.SeekResetThreshold {
+		return
+	}
+
+	others := make([]string, 0)
+	for _, username := range roomUsernames(room) {
+		if username != connection.Username {
+			others = append(others, username)
+		}
+	}
+	resetReadiness(room, others, connection.Username+" seeked")
+}
+
+// roomUsernames returns the usernames of everyone in the room
+func roomUsernames(room *roomM.Room) []string {
+	connections := room.GetConnections()
+	usernames := make([]string, 0, len(connections))
+	for _, connection := range connections {
+		usernames = append(usernames, connection.Username)
+	}
+	return usernames
+}
+
+func handleReadyResetCommand(connection roomM.Connection, args string) {
+	if !requireOperator(connection) {
+		return
+	}
+
+	usage := "Usage: /readyreset index|file|seek on|off [seconds]"
+	fields := strings.Fields(args)
+	if len(fields) < 2 || (fields[1] != "on" && fields[1] != "off") {
+		sendServerNotice(connection, usage)
+		return
+	}
+
+	enabled := fields[1] == "on"
+	settings := connection.Owner.GetSettings()
+	switch fields[0] {
+	case "index":
+		settings.ResetReadyOnIndexChange = enabled
+	case "file":
+		settings.ResetReadyOnFileChange = enabled
+	case "seek":
+		settings.ResetReadyOnSeek = enabled
+		if len(fields) > 2 {
+			seconds, err := strconv.ParseFloat(fields[2], 64)
+			if err != nil || seconds < 0 {
+				sendServerNotice(connection, usage)
+				return
+			}
+			settings.SeekResetThreshold = seconds
+		}
+	default:
+		sendServerNotice(connection, usage)
+		return
+	}
+	connection.Owner.SetSettings(settings)
+
+	SendServerChatMessage(connection.Owner, fmt.Sprintf("Readiness reset on %s: %s", fields[0], fields[1]))
+}
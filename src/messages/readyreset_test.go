@@ -0,0 +1,72 @@
+package messages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResetReadiness(t *testing.T) {
+	cm, room := newTestRoom(t)
+	settings := room.GetSettings()
+	settings.ResetReadyOnSeek = true
+	room.SetSettings(settings)
+
+	alice, _ := joinTestRoom(t, cm, room, "alice")
+	bob, bobConn := joinTestRoom(t, cm, room, "bob")
+	readyAll := func() {
+		room.SetUserReadyState(alice.Username, true, true)
+		room.SetUserReadyState(bob.Username, true, true)
+		bobConn.messages(t)
+	}
+	isReady := func(username string) bool {
+		state, _ := room.ReadyManager.GetUserReadyState(username)
+		return state.IsReady
+	}
+
+	room.PlaylistManager.SetFiles([]string{"a.mkv", "b.mkv"}, alice.Username)
+	assert.NoError(t, room.PlaylistManager.SetIndex(0, alice.Username))
+
+	// Test case 1: changing the playlist index resets everyone and broadcasts it
+	readyAll()
+	index := 1
+	HandlePlaylistIndexMessage(*alice, &ClientPlaylistIndexMessage{Index: &index})
+	assert.False(t, isReady(alice.Username))
+	assert.False(t, isReady(bob.Username))
+	broadcasts := readyBroadcasts(bobConn.messages(t))
+	if assert.Len(t, broadcasts, 2) {
+		assert.Equal(t, "alice", broadcasts[0]["username"])
+		assert.Equal(t, false, broadcasts[0]["isReady"])
+		assert.Equal(t, "bob", broadcasts[1]["username"])
+	}
+
+	// Test case 2: opening a different file resets only that user
+	HandleFileMessage(*bob, &ClientFileMessage{Duration: 600, Name: "a.mkv", Size: 1.0})
+	readyAll()
+	HandleFileMessage(*bob, &ClientFileMessage{Duration: 600, Name: "b.mkv", Size: 1.0})
+	assert.True(t, isReady(alice.Username))
+	assert.False(t, isReady(bob.Username))
+	broadcasts = readyBroadcasts(bobConn.messages(t))
+	if assert.Len(t, broadcasts, 1) {
+		assert.Equal(t, "bob", broadcasts[0]["username"])
+	}
+
+	now := float64(time.Now().UnixNano()) / 1e9
+
+	// Test case 3: a seek within the threshold keeps everyone ready
+	readyAll()
+	assert.NoError(t, UpdateGlobalState(*alice, 30.0, true, true, "alice", now, now, 1))
+	assert.True(t, isReady(bob.Username))
+	assert.Empty(t, readyBroadcasts(bobConn.messages(t)))
+
+	// Test case 4: a seek beyond the threshold resets everyone but the seeker
+	assert.NoError(t, UpdateGlobalState(*alice, 200.0, true, true, "alice", now, now, 1))
+	assert.True(t, isReady(alice.Username))
+	assert.False(t, isReady(bob.Username))
+	broadcasts = readyBroadcasts(bobConn.messages(t))
+	if assert.Len(t, broadcasts, 1) {
+		assert.Equal(t, "bob", broadcasts[0]["username"])
+		assert.Equal(t, false, broadcasts[0]["isReady"])
+	}
+}
@@ -0,0 +1,97 @@
+package messages
+
+import (
+	"strings"
+
+	Features "github.com/Icey-Glitch/Syncplay-G/features"
+	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
+)
+
+func init() {
+	registerChatCommand("spectator", "/spectator <user> on|off - make a user a spectator who can't control playback (operators only)", handleSpectatorCommand)
+	registerChatCommand("spectators", "/spectators join|chat on|off - let new users join as spectators, let spectators chat (operators only)", handleSpectatorsCommand)
+}
+
+// SetupSpectator makes a user who just entered a room a spectator when they asked for it or the
+// room lets everyone join as one. Operators only become spectators when they ask.
+func SetupSpectator(connection roomM.Connection, requested bool) {
+	room := connection.Owner
+	if room == nil {
+		return
+	}
+
+	if requested || (room.GetSettings().JoinAsSpectator && !room.IsOperator(connection.Username)) {
+		room.AddSpectator(connection.Username)
+		sendServerNotice(connection, "You are a spectator in this room, playback follows the others")
+	}
+}
+
+// rejectSpectator sends a notice and returns true if the user is a spectator, who can't change
+// what is named by action
+func rejectSpectator(connection roomM.Connection, action string) bool {
+	if connection.Owner == nil || !connection.Owner.IsSpectator(connection.Username) {
+		return false
+	}
+
+	sendServerNotice(connection, "Spectators can't change "+action)
+	return true
+}
+
+// rejectSpectatorPlaylist undoes a shared playlist change of a spectator on their side
+func rejectSpectatorPlaylist(connection roomM.Connection) bool {
+	if !Features.GlobalFeatures.SharedPlaylists || !rejectSpectator(connection, "the playlist") {
+		return false
+	}
+
+	SendPlaylistToUser(connection)
+	return true
+}
+
+func handleSpectatorCommand(connection roomM.Connection, args string) {
+	if !requireOperator(connection) {
+		return
+	}
+
+	room := connection.Owner
+	fields := strings.Fields(args)
+	if len(fields) != 2 || (fields[1] != "on" && fields[1] != "off") || room.GetConnectionByUsername(fields[0]) == nil {
+		sendServerNotice(connection, "Usage: /spectator <user in this room> on|off")
+		return
+	}
+
+	username := fields[0]
+	if fields[1] == "on" {
+		room.AddSpectator(username)
+		SendServerChatMessage(room, username+" is now a spectator")
+	} else {
+		room.RemoveSpectator(username)
+		SendServerChatMessage(room, username+" is no longer a spectator")
+	}
+}
+
+func handleSpectatorsCommand(connection roomM.Connection, args string) {
+	if !requireOperator(connection) {
+		return
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) != 2 || (fields[1] != "on" && fields[1] != "off") {
+		sendServerNotice(connection, "Usage: /spectators join|chat on|off")
+		return
+	}
+
+	enabled := fields[1] == "on"
+	settings := connection.Owner.GetSettings()
+	switch fields[0] {
+	case "join":
+		settings.JoinAsSpectator = enabled
+	case "chat":
+		settings.SpectatorChat = enabled
+	default:
+		sendServerNotice(connection, "Usage: /spectators join|chat on|off")
+		return
+	}
+	connection.Owner.SetSettings(settings)
+
+	SendServerChatMessage(connection.Owner, "Spectators "+fields[0]+": "+fields[1])
+}
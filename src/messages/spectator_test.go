@@ -0,0 +1,34 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpectatorMovesRoom(t *testing.T) {
+	cm, room := newGlobalTestRoom(t)
+
+	alice, _ := joinTestRoom(t, cm, room, "alice")
+	bob, bobConn := joinTestRoom(t, cm, room, "bob")
+	t.Cleanup(func() {
+		cm.RemoveConnection(alice.Conn)
+		cm.RemoveConnection(bob.Conn)
+	})
+
+	bob.RequestedSpectator = true
+	SetupSpectator(*bob, bob.RequestedSpectator)
+	assert.True(t, room.IsSpectator(bob.Username))
+
+	// Test case 1: a user who joined as a spectator stays one in the room they move to
+	HandleUserMoveRoomMessage(*bob, &RoomMessage{Name: t.Name() + "-other"})
+	other := cm.GetRoom(t.Name() + "-other")
+	if assert.NotNil(t, other) {
+		assert.True(t, other.IsSpectator(bob.Username))
+	}
+	assert.Contains(t, bobConn.chats(t), "You are a spectator in this room, playback follows the others")
+
+	// Test case 2: everyone else moves as a regular user
+	HandleUserMoveRoomMessage(*alice, &RoomMessage{Name: t.Name() + "-other"})
+	assert.False(t, other.IsSpectator(alice.Username))
+}
@@ -0,0 +1,42 @@
+package messages
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Icey-Glitch/Syncplay-G/mngr/playlists"
+	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
+)
+
+// speedTolerance is the smallest speed change that is applied to the room
+const speedTolerance = 0.001
+
+// UpdateRoomSpeed applies the playback speed a user reported to the room clock. Users who may not
+// control the room, and speeds outside the allowed range, are put back on the room speed. Clients
+// that did not declare speed support are ignored.
+func UpdateRoomSpeed(connection roomM.Connection, speed float64, now float64) {
+	room := connection.Owner
+	if room == nil || !connection.SupportsSpeed {
+		return
+	}
+
+	clock := room.PlaylistManager.GetClock(now)
+	if math.Abs(speed-clock.Speed) < speedTolerance {
+		return
+	}
+
+	presenter := room.GetPresenter()
+	if room.IsSpectator(connection.Username) || (presenter != "" && presenter != connection.Username) {
+		sendUserState(connection, true)
+		return
+	}
+
+	err := room.PlaylistManager.SetSpeed(speed, connection.Username, now)
+	if err != nil {
+		sendServerNotice(connection, fmt.Sprintf("Playback speed must be between %gx and %gx", playlists.MinSpeed, playlists.MaxSpeed))
+		sendUserState(connection, true)
+		return
+	}
+
+	broadcastRoomState(room)
+}
@@ -0,0 +1,42 @@
+package messages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateRoomSpeed(t *testing.T) {
+	cm, room := newTestRoom(t)
+	alice, _ := joinTestRoom(t, cm, room, "alice")
+	bob, bobConn := joinTestRoom(t, cm, room, "bob")
+	carol, carolConn := joinTestRoom(t, cm, room, "carol")
+	alice.SupportsSpeed = true
+	bob.SupportsSpeed = true
+
+	now := float64(time.Now().UnixNano()) / 1e9
+
+	// Test case 1: a speed change reaches the other users, older clients get plain playstates
+	UpdateRoomSpeed(*alice, 1.5, now)
+	assert.Equal(t, 1.5, room.PlaylistManager.GetClock(now).Speed)
+	state := lastState(t, bobConn.messages(t))
+	if assert.NotNil(t, state) {
+		assert.Equal(t, 1.5, state["speed"])
+		assert.Equal(t, "alice", state["setBy"])
+	}
+	state = lastState(t, carolConn.messages(t))
+	if assert.NotNil(t, state) {
+		assert.NotContains(t, state, "speed")
+	}
+
+	// Test case 2: clients without speed support can't change it
+	UpdateRoomSpeed(*carol, 2.0, now)
+	assert.Equal(t, 1.5, room.PlaylistManager.GetClock(now).Speed)
+	assert.Nil(t, lastState(t, bobConn.messages(t)))
+
+	// Test case 3: speeds out of range put the user back on the room speed
+	UpdateRoomSpeed(*bob, 10, now)
+	assert.Equal(t, 1.5, room.PlaylistManager.GetClock(now).Speed)
+	assert.Equal(t, 1.5, lastState(t, bobConn.messages(t))["speed"])
+}
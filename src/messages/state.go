@@ -23,6 +23,7 @@ type ClientStateMessage struct {
 		Position float64     `json:"position"`
 		DoSeek   bool        `json:"doSeek,omitempty"`
 		SetBy    interface{} `json:"setBy,omitempty"`
+		Speed    *float64    `json:"speed,omitempty"`
 	} `json:"playstate"`
 	//} `json:"State"`
 }
@@ -37,6 +38,7 @@ type ServerStateMessage struct {
 			Paused   bool        `json:"paused"`
 			DoSeek   bool        `json:"doSeek"`
 			SetBy    interface{} `json:"setBy"`
+			Speed    *float64    `json:"speed,omitempty"`
 		} `json:"playstate"`
 		Ping struct {
 			LatencyCalculation       float64 `json:"latencyCalculation"`
@@ -63,55 +65,33 @@ func SendInitialState(connection roomM.Connection) {
 		return
 	}
 
-	if len(connection.Owner.Users) <= 1 {
-		stateMessage := ServerStateMessage{}
-		stateMessage.State.Ping.LatencyCalculation = float64(time.Now().UnixNano()) / 1e9
-		stateMessage.State.Ping.ServerRtt = 0
-		stateMessage.State.Playstate.DoSeek = false
-		stateMessage.State.Playstate.Position = 0
-		stateMessage.State.Playstate.Paused = true
-		stateMessage.State.Playstate.SetBy = "Nobody"
-
-		err := utils.SendJSONMessage(connection.Conn, stateMessage)
-		if err != nil {
-			fmt.Println("Error sending initial state message:", err)
-			return
-		}
-		return
-	}
-
-	roomState := connection.Owner.PlaylistManager.Playlist
-	stateMessage := ServerStateMessage{}
-	stateMessage.State.Ping.LatencyCalculation = float64(time.Now().UnixNano()) / 1e9
-	stateMessage.State.Ping.ServerRtt = 0
-
-	stateMessage.State.Playstate.DoSeek = false
-	stateMessage.State.Playstate.Position = roomState.Position
-	stateMessage.State.Playstate.Paused = roomState.Paused
+	clock := connection.Owner.PlaylistManager.GetClock(float64(time.Now().UnixNano()) / 1e9)
 
-	err := utils.SendJSONMessage(connection.Conn, stateMessage)
+	// a joiner has nothing to seek from yet
+	err := sendStateMessage(connection.Owner, connection, clock.Position, clock.Paused, false, clock.SetBy, clock.Speed, false)
 	if err != nil {
 		fmt.Println("Error sending initial state message:", err)
 		return
 	}
 }
 
+// SendUserState sends the room clock to a single user as a keepalive
 func SendUserState(connection roomM.Connection) bool {
-	//fmt.Println("Sending user state")
+	return sendUserState(connection, false)
+}
 
-	latencyCalculation, err := connection.Owner.GetUsersLatencyCalculation(&connection)
+// sendUserState sends the room clock to a single user. Forced states must be followed by the
+// client, which then has to acknowledge them before its own playstate is accepted again.
+func sendUserState(connection roomM.Connection, forced bool) bool {
+	_, err := connection.Owner.GetUsersLatencyCalculation(&connection)
 	if err != nil {
 		fmt.Println("Error getting user latency calculation:", err)
 		return true
 	}
 
-	processingTime := float64(time.Now().UnixNano())/1e9 - latencyCalculation.ArivalTime
-
-	yourLatency := latencyCalculation.ClientTime - connection.Owner.PlaylistManager.GetLastMessageAge(connection.Username)
+	clock := connection.Owner.PlaylistManager.GetClock(float64(time.Now().UnixNano()) / 1e9)
 
-	Ignore := connection.Owner.PlaylistManager.Playlist.Ignore
-
-	err = sendStateMessage(connection.Owner, connection.Conn, connection.Owner.PlaylistManager.Playlist.Position, connection.Owner.PlaylistManager.Playlist.Paused, connection.Owner.PlaylistManager.Playlist.DoSeek, processingTime, connection.Owner.PlaylistManager.Playlist.SetBy, latencyCalculation.ClientTime, connection.Username, yourLatency, Ignore)
+	err = sendStateMessage(connection.Owner, connection, clock.Position, clock.Paused, clock.DoSeek, clock.SetBy, clock.Speed, forced)
 	if err != nil {
 		fmt.Println("Error sending state message:", err)
 		return true
@@ -129,30 +109,41 @@ func SendGlobalState(connection roomM.Connection) {
 		return
 	}
 
-	for _, user := range connection.Owner.Users {
-		if user.Conn != nil {
-			SendUserState(*user)
+	broadcastRoomState(connection.Owner)
+}
+
+// broadcastRoomState forces the room state on every user in the room
+func broadcastRoomState(room *roomM.Room) {
+	broadcastRoomStateExcept(room, "")
+}
+
+// broadcastRoomStateExcept forces the room state on every user in the room but skip
+func broadcastRoomStateExcept(room *roomM.Room, skip string) {
+	for _, user := range room.GetConnections() {
+		if user.Conn != nil && user.Username != skip {
+			sendUserState(*user, true)
 		}
 	}
-
-	connection.Owner.PlaylistManager.SetIgnoreInt(0)
 }
 
-func sendStateMessage(room *roomM.Room, conn net.Conn, position float64, paused bool, doSeek bool, processingTime float64, stateChange string, clientTime float64, usr string, LastMsgAge float64, Ignore float64) error {
+func sendStateMessage(room *roomM.Room, connection roomM.Connection, position float64, paused bool, doSeek bool, stateChange string, speed float64, forced bool) error {
 	if room == nil {
 		return fmt.Errorf("room cannot be nil")
 	}
 
-	if conn == nil {
+	if connection.Conn == nil {
 		return fmt.Errorf("connection cannot be nil")
 	}
 
+	now := float64(time.Now().UnixNano()) / 1e9
+
 	stateMessage := ServerStateMessage{}
-	stateMessage.State.Ping.LatencyCalculation = float64(time.Now().UnixNano()) / 1e9
-	// if clientTime != 0 {
-	// 	stateMessage.State.Ping.SenderLatency = clientTime + processingTime
-	// }
-	// stateMessage.State.Ping.YourLatency = LastMsgAge
+	stateMessage.State.Ping.LatencyCalculation = now
+	stateMessage.State.Ping.ServerRtt = connection.Latency.Rtt()
+	// the client adds its own forward delay to the position once it knows its RTT from this echo
+	if clientTime, ok := connection.Latency.TakeClientTimestamp(now); ok {
+		stateMessage.State.Ping.ClientLatencyCalculation = clientTime
+	}
 
 	stateMessage.State.Playstate.Position = position
 	stateMessage.State.Playstate.Paused = paused
@@ -161,14 +152,17 @@ func sendStateMessage(room *roomM.Room, conn net.Conn, position float64, paused
 		stateChange = "Nobody"
 	}
 	stateMessage.State.Playstate.SetBy = stateChange
+	// only clients that declared support get the speed, older ones would not extrapolate with it
+	if connection.SupportsSpeed {
+		stateMessage.State.Playstate.Speed = &speed
+	}
 
-	if Ignore != 0 {
-		stateMessage.State.IgnoringOnTheFly = &IgnoringOnTheFly{}
-		stateMessage.State.IgnoringOnTheFly.Client = Ignore
-		stateMessage.State.IgnoringOnTheFly.Server = Ignore
+	server, client := connection.OnTheFly.Next(forced)
+	if server != 0 || client != 0 {
+		stateMessage.State.IgnoringOnTheFly = &IgnoringOnTheFly{Client: client, Server: server}
 	}
 
-	err := utils.SendJSONMessage(conn, stateMessage)
+	err := utils.SendJSONMessage(connection.Conn, stateMessage)
 	if err != nil {
 		return fmt.Errorf("error sending JSON message: %w", err)
 	}
@@ -225,42 +219,71 @@ func HandleStatePing(ping map[string]interface{}) (clientRTT float64, latencyCal
 	return clientRTT, latencyCalculation, clientLatencyCalculation, nil
 }
 
-var globalState = struct {
-	position float64
-	paused   bool
-	doSeek   bool
-	setBy    interface{}
-}{}
-
+// UpdateGlobalState applies a user's playstate to the room clock. messageAge is when the state
+// arrived, Ignore the client's ignoringOnTheFly counter, set when the user changed the state on purpose.
+// Pauses and seeks that change the room are forced on everyone.
 func UpdateGlobalState(connection roomM.Connection, position, paused, doSeek, setBy interface{}, messageAge float64, latencyCalculation float64, Ignore float64) error {
 
 	room := connection.Owner
 
-	globalState.position = position.(float64)
-	globalState.paused = paused.(bool)
-	globalState.doSeek = doSeek.(bool)
-	globalState.setBy = setBy
-
-	// store IgnoreOnTheFly
-	if Ignore != 0 {
-		room.PlaylistManager.SetIgnoreInt(Ignore)
-		setBy = connection.Username
-		err := room.PlaylistManager.SetUserPlaystate(connection.Username, position.(float64), paused.(bool), doSeek.(bool), setBy.(string), messageAge, true)
-		if err != nil {
-			return fmt.Errorf("error storing user playstate: %w", err)
-		}
-	} else {
-		err := room.PlaylistManager.SetUserPlaystate(connection.Username, position.(float64), paused.(bool), doSeek.(bool), setBy.(string), messageAge, false)
-		if err != nil {
-			return fmt.Errorf("error storing user playstate: %w", err)
-		}
+	// the command was sent one forward delay before it arrived, the clock extrapolates from there
+	sentAt := messageAge - connection.Latency.ForwardDelay()
+
+	// spectators and everyone but the presenter follow the room without controlling it
+	presenter := room.GetPresenter()
+	if room.IsSpectator(connection.Username) || (presenter != "" && presenter != connection.Username) {
+		followRoom(connection, position.(float64), paused.(bool), doSeek.(bool), sentAt)
+		return nil
 	}
 
+	resetReadinessOnSeek(connection, position.(float64), doSeek.(bool))
+
+	// whoever changes the room clock is the one who set it
+	setBy = connection.Username
+
+	before := room.PlaylistManager.GetClock(sentAt)
+
+	err := room.PlaylistManager.SetUserPlaystate(connection.Username, position.(float64), paused.(bool), doSeek.(bool), setBy.(string), sentAt, Ignore != 0)
+	if err != nil {
+		return fmt.Errorf("error storing user playstate: %w", err)
+	}
+
+	// older commands than the current clock are dropped, the user catches up with the next state
+	after := room.PlaylistManager.GetClock(messageAge)
+	changed := paused.(bool) != before.Paused || doSeek.(bool)
+	applied := after.SetBy == connection.Username && after.Paused == paused.(bool) && (!doSeek.(bool) || after.DoSeek)
+	if changed && applied {
+		broadcastRoomState(room)
+	} else if presenter == connection.Username && room.PlaylistManager.GetClock(sentAt).Position != before.Position {
+		// the presenter drifted and moved the clock, everyone else follows right away
+		broadcastRoomStateExcept(room, connection.Username)
+	}
+
+	checkDesync(room, connection.Username)
+
+	checkEndOfFile(room)
+
 	return nil
 }
 
-func GetLocalState() (interface{}, interface{}, interface{}, interface{}) {
-	return globalState.position, globalState.paused, globalState.doSeek, globalState.setBy
+// followRoom handles the playstate of a user who may not control the room. Pauses and seeks are
+// ignored and the user is put back on the room state, anything else is only tracked.
+func followRoom(connection roomM.Connection, position float64, paused bool, doSeek bool, sentAt float64) {
+	room := connection.Owner
+
+	clock := room.PlaylistManager.GetClock(sentAt)
+	if paused != clock.Paused || doSeek {
+		sendUserState(connection, true)
+		return
+	}
+
+	err := room.PlaylistManager.RecordUserPlaystate(connection.Username, position, paused, doSeek, sentAt)
+	if err != nil {
+		fmt.Println("Error storing user playstate:", err)
+		return
+	}
+
+	checkDesync(room, connection.Username)
 }
 
 type UserMessage struct {
@@ -0,0 +1,64 @@
+package messages
+
+import (
+	"strings"
+
+	Features "github.com/Icey-Glitch/Syncplay-G/features"
+	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
+)
+
+func init() {
+	registerChatCommand("strict", "/strict on|off - only allow unpausing once everyone is ready (operators only)", handleStrictCommand)
+}
+
+// RejectUnpause reports whether an unpause request must be refused because the room is in
+// strict readiness mode and not everyone is ready. Rejected users are sent the paused state.
+func RejectUnpause(connection roomM.Connection, paused bool) bool {
+	room := connection.Owner
+	if room == nil || paused || !Features.GlobalFeatures.Readiness || room.IsSpectator(connection.Username) {
+		return false
+	}
+
+	if !room.PlaylistManager.GetUserPauseState() {
+		return false
+	}
+
+	notReady := unpauseBlockedBy(room)
+	if len(notReady) == 0 {
+		return false
+	}
+
+	sendUserState(connection, true)
+	sendServerNotice(connection, "Can't unpause, waiting for: "+strings.Join(notReady, ", "))
+	return true
+}
+
+// unpauseBlockedBy returns the users who keep the room from being unpaused in strict readiness
+// mode, nobody when the room does not use it
+func unpauseBlockedBy(room *roomM.Room) []string {
+	if !Features.GlobalFeatures.Readiness || !room.GetSettings().StrictReadiness {
+		return nil
+	}
+	return room.UsersNotReady()
+}
+
+func handleStrictCommand(connection roomM.Connection, args string) {
+	if !requireOperator(connection) {
+		return
+	}
+
+	if args != "on" && args != "off" {
+		sendServerNotice(connection, "Usage: /strict on|off")
+		return
+	}
+
+	settings := connection.Owner.GetSettings()
+	settings.StrictReadiness = args == "on"
+	connection.Owner.SetSettings(settings)
+
+	if settings.StrictReadiness {
+		SendServerChatMessage(connection.Owner, "Strict readiness enabled, playback can only start once everyone is ready")
+	} else {
+		SendServerChatMessage(connection.Owner, "Strict readiness disabled")
+	}
+}
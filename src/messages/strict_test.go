@@ -0,0 +1,37 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRejectUnpause(t *testing.T) {
+	cm, room := newTestRoom(t)
+	settings := room.GetSettings()
+	settings.StrictReadiness = true
+	room.SetSettings(settings)
+
+	alice, aliceConn := joinTestRoom(t, cm, room, "alice")
+	bob, _ := joinTestRoom(t, cm, room, "bob")
+	room.SetUserReadyState(alice.Username, true, true)
+	room.SetUserReadyState(bob.Username, false, true)
+
+	// Test case 1: unpausing is refused while someone is not ready
+	assert.True(t, RejectUnpause(*alice, false))
+
+	// Test case 2: the rejected client is put back on the paused state and told why
+	messages := aliceConn.messages(t)
+	playstate := lastState(t, messages)
+	assert.NotNil(t, playstate)
+	assert.Equal(t, true, playstate["paused"])
+	assert.Contains(t, messages[len(messages)-1]["Chat"].(map[string]interface{})["message"], "waiting for: bob")
+
+	// Test case 3: pausing is always allowed
+	assert.False(t, RejectUnpause(*alice, true))
+
+	// Test case 4: unpausing is allowed once everyone is ready
+	room.SetUserReadyState(bob.Username, true, true)
+	assert.False(t, RejectUnpause(*alice, false))
+	assert.Empty(t, aliceConn.messages(t))
+}
@@ -0,0 +1,96 @@
+package messages
+
+import (
+	"strings"
+
+	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
+)
+
+func init() {
+	registerChatCommand("trust", "/trust list|add <domain>|remove <domain> - manage trusted playlist domains (operators can change them)", handleTrustCommand)
+	registerChatCommand("schemes", "/schemes <scheme,...> - set the URL schemes allowed in the playlist, empty allows all (operators only)", handleSchemesCommand)
+}
+
+// notifyRejectedEntries tells a user which of their playlist entries were dropped and why
+func notifyRejectedEntries(connection roomM.Connection, rejected []error) {
+	reasons := make([]string, 0, len(rejected))
+	for _, err := range rejected {
+		reasons = append(reasons, err.Error())
+	}
+	sendServerNotice(connection, "Removed from the playlist:\n"+strings.Join(reasons, "\n"))
+}
+
+func handleTrustCommand(connection roomM.Connection, args string) {
+	if connection.Owner == nil {
+		return
+	}
+
+	action, domain, _ := strings.Cut(args, " ")
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	settings := connection.Owner.GetSettings()
+
+	if action == "list" || action == "" {
+		rules := settings.TrustRules
+		domains := "any"
+		if len(rules.Domains) > 0 {
+			domains = strings.Join(rules.Domains, ", ")
+		}
+		schemes := "any"
+		if len(rules.Schemes) > 0 {
+			schemes = strings.Join(rules.Schemes, ", ")
+		}
+		sendServerNotice(connection, "Trusted domains: "+domains+"\nAllowed schemes: "+schemes)
+		return
+	}
+
+	if !requireOperator(connection) {
+		return
+	}
+
+	if domain == "" || (action != "add" && action != "remove") {
+		sendServerNotice(connection, "Usage: /trust list|add <domain>|remove <domain>")
+		return
+	}
+
+	// build a new slice, the settings copy shares its backing array with the room
+	domains := make([]string, 0, len(settings.TrustRules.Domains)+1)
+	for _, d := range settings.TrustRules.Domains {
+		if d != domain {
+			domains = append(domains, d)
+		}
+	}
+	if action == "add" {
+		domains = append(domains, domain)
+	}
+	settings.TrustRules.Domains = domains
+	connection.Owner.SetSettings(settings)
+
+	if action == "add" {
+		SendServerChatMessage(connection.Owner, domain+" is now a trusted domain")
+	} else {
+		SendServerChatMessage(connection.Owner, domain+" is no longer a trusted domain")
+	}
+}
+
+func handleSchemesCommand(connection roomM.Connection, args string) {
+	if !requireOperator(connection) {
+		return
+	}
+
+	schemes := make([]string, 0)
+	for _, scheme := range strings.Split(args, ",") {
+		if scheme = strings.ToLower(strings.TrimSpace(scheme)); scheme != "" {
+			schemes = append(schemes, scheme)
+		}
+	}
+
+	settings := connection.Owner.GetSettings()
+	settings.TrustRules.Schemes = schemes
+	connection.Owner.SetSettings(settings)
+
+	if len(schemes) == 0 {
+		SendServerChatMessage(connection.Owner, "All URL schemes are now allowed in the playlist")
+	} else {
+		SendServerChatMessage(connection.Owner, "Allowed URL schemes: "+strings.Join(schemes, ", "))
+	}
+}
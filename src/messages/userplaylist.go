@@ -0,0 +1,60 @@
+package messages
+
+import (
+	"fmt"
+
+	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
+	"github.com/Icey-Glitch/Syncplay-G/utils"
+)
+
+// Without shared playlists every user has their own playlist and index,
+// which are only ever sent back to that user.
+
+func handleUserPlaylistChange(connection roomM.Connection, msg *ClientPlaylistChangeMessage) {
+	err := connection.Owner.PlaylistManager.SetUserFiles(connection.Username, msg.Files)
+	if err != nil {
+		fmt.Println("Error: failed to store playlist of", connection.Username, ":", err)
+		return
+	}
+
+	sendUserPlaylist(connection)
+}
+
+func handleUserPlaylistIndex(connection roomM.Connection, msg *ClientPlaylistIndexMessage) {
+	if msg.Index != nil {
+		err := connection.Owner.PlaylistManager.SetUserIndex(connection.Username, *msg.Index)
+		if err != nil {
+			fmt.Println("Error: rejected playlist index from", connection.Username, ":", err)
+		}
+	}
+
+	sendUserPlaylistIndex(connection)
+}
+
+// sendUserPlaylist sends a user their own playlist and index
+func sendUserPlaylist(connection roomM.Connection) {
+	playlistChangeMessage := PlaylistChangeMessage{}
+	playlistChangeMessage.Set.PlaylistChange.User = connection.Username
+	playlistChangeMessage.Set.PlaylistChange.Files = connection.Owner.PlaylistManager.GetUserFileNames(connection.Username)
+
+	err := utils.SendJSONMessage(connection.Conn, playlistChangeMessage)
+	if err != nil {
+		fmt.Println("Error sending playlist to", connection.Username, ":", err)
+		return
+	}
+
+	sendUserPlaylistIndex(connection)
+}
+
+func sendUserPlaylistIndex(connection roomM.Connection) {
+	playlistIndexMessage := PlaylistIndexMessage{}
+	playlistIndexMessage.Set.PlaylistIndex.User = connection.Username
+	if index, ok := connection.Owner.PlaylistManager.GetUserIndex(connection.Username); ok {
+		playlistIndexMessage.Set.PlaylistIndex.Index = index
+	}
+
+	err := utils.SendJSONMessage(connection.Conn, playlistIndexMessage)
+	if err != nil {
+		fmt.Println("Error sending playlist index to", connection.Username, ":", err)
+	}
+}
@@ -0,0 +1,304 @@
+package chatlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// Entry is a single chat line as written to the transcript
+type Entry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Username  string    `json:"username"`
+	Room      string    `json:"room"`
+	Message   string    `json:"message"`
+}
+
+// Options controls where transcripts are written and how they are rotated
+type Options struct {
+	Dir        string
+	MaxSize    int64         // rotate the active file once it reaches this many bytes, 0 disables
+	MaxAge     time.Duration // rotate the active file once it is older than this, 0 disables
+	Retention  time.Duration // delete rotated files older than this, 0 keeps them forever
+	MaxBackups int           // keep at most this many rotated files per room, 0 keeps all
+	BufferSize int           // number of entries queued before new ones are dropped
+}
+
+type roomFile struct {
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+// Logger writes chat messages to one rotating JSONL file per room.
+// Entries are queued and written by a single goroutine so Log never blocks the caller.
+type Logger struct {
+	opts    Options
+	entries chan Entry
+	files   map[string]*roomFile
+	mutex   sync.RWMutex
+	closed  bool
+	dropped atomic.Int64
+	done    chan struct{}
+}
+
+const maintenanceInterval = time.Minute
+
+// NewLogger creates the transcript directory and starts the writer goroutine
+func NewLogger(opts Options) (*Logger, error) {
+	if opts.Dir == "" {
+		return nil, fmt.Errorf("chat log directory cannot be empty")
+	}
+
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 256
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create chat log directory: %w", err)
+	}
+
+	l := &Logger{
+		opts:    opts,
+		entries: make(chan Entry, opts.BufferSize),
+		files:   make(map[string]*roomFile),
+		done:    make(chan struct{}),
+	}
+
+	go l.run()
+	return l, nil
+}
+
+// Log queues an entry for writing. If the queue is full the entry is dropped
+func (l *Logger) Log(entry Entry) {
+	if l == nil {
+		return
+	}
+
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	if l.closed {
+		return
+	}
+
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+
+	select {
+	case l.entries <- entry:
+	default:
+		// Drop the entry rather than hold up chat delivery
+		l.dropped.Add(1)
+	}
+}
+
+// Dropped returns how many entries were discarded because the queue was full
+func (l *Logger) Dropped() int {
+	return int(l.dropped.Load())
+}
+
+// Close flushes queued entries and closes all open files
+func (l *Logger) Close() {
+	l.mutex.Lock()
+	if l.closed {
+		l.mutex.Unlock()
+		return
+	}
+	l.closed = true
+	close(l.entries)
+	l.mutex.Unlock()
+
+	<-l.done
+}
+
+func (l *Logger) run() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(maintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case entry, ok := <-l.entries:
+			if !ok {
+				for _, rf := range l.files {
+					rf.file.Close()
+				}
+				return
+			}
+			if err := l.write(entry); err != nil {
+				fmt.Println("Error writing chat log entry:", err)
+			}
+		case <-ticker.C:
+			l.maintain(time.Now())
+		}
+	}
+}
+
+func (l *Logger) write(entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	room := sanitizeRoomName(entry.Room)
+	rf, err := l.open(room)
+	if err != nil {
+		return err
+	}
+
+	if l.needsRotation(rf, int64(len(data)), entry.Timestamp) {
+		if err = l.rotate(room, entry.Timestamp); err != nil {
+			return err
+		}
+		if rf, err = l.open(room); err != nil {
+			return err
+		}
+	}
+
+	n, err := rf.file.Write(data)
+	rf.size += int64(n)
+	return err
+}
+
+func (l *Logger) open(room string) (*roomFile, error) {
+	if rf, ok := l.files[room]; ok {
+		return rf, nil
+	}
+
+	file, err := os.OpenFile(l.activePath(room), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chat log: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat chat log: %w", err)
+	}
+
+	rf := &roomFile{file: file, size: info.Size(), opened: time.Now()}
+	if info.Size() > 0 {
+		// an existing transcript is at least as old as its last write
+		rf.opened = info.ModTime()
+	}
+
+	l.files[room] = rf
+	return rf, nil
+}
+
+func (l *Logger) needsRotation(rf *roomFile, incoming int64, now time.Time) bool {
+	if rf.size == 0 {
+		return false
+	}
+	if l.opts.MaxSize > 0 && rf.size+incoming > l.opts.MaxSize {
+		return true
+	}
+	if l.opts.MaxAge > 0 && now.Sub(rf.opened) >= l.opts.MaxAge {
+		return true
+	}
+	return false
+}
+
+// rotate closes the active file of a room, renames it with a timestamp and applies the retention policy
+func (l *Logger) rotate(room string, now time.Time) error {
+	if rf, ok := l.files[room]; ok {
+		rf.file.Close()
+		delete(l.files, room)
+	}
+
+	stamp := now.UTC().Format("20060102T150405")
+	target := filepath.Join(l.opts.Dir, room+"."+stamp+".jsonl")
+	for i := 1; fileExists(target); i++ {
+		target = filepath.Join(l.opts.Dir, fmt.Sprintf("%s.%s-%d.jsonl", room, stamp, i))
+	}
+
+	if err := os.Rename(l.activePath(room), target); err != nil {
+		return fmt.Errorf("failed to rotate chat log: %w", err)
+	}
+
+	l.prune(room, now)
+	return nil
+}
+
+// prune removes rotated files of a room that fall outside the retention policy
+func (l *Logger) prune(room string, now time.Time) {
+	backups, err := filepath.Glob(filepath.Join(l.opts.Dir, room+".*.jsonl"))
+	if err != nil {
+		return
+	}
+
+	// timestamps sort lexically, so the newest files end up last
+	sort.Strings(backups)
+
+	keep := make([]string, 0, len(backups))
+	for _, path := range backups {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if l.opts.Retention > 0 && now.Sub(info.ModTime()) > l.opts.Retention {
+			os.Remove(path)
+			continue
+		}
+		keep = append(keep, path)
+	}
+
+	if l.opts.MaxBackups > 0 && len(keep) > l.opts.MaxBackups {
+		for _, path := range keep[:len(keep)-l.opts.MaxBackups] {
+			os.Remove(path)
+		}
+	}
+}
+
+// maintain rotates files that aged out while their room was idle
+func (l *Logger) maintain(now time.Time) {
+	for room, rf := range l.files {
+		if l.needsRotation(rf, 0, now) {
+			if err := l.rotate(room, now); err != nil {
+				fmt.Println("Error rotating chat log:", err)
+			}
+		} else {
+			l.prune(room, now)
+		}
+	}
+}
+
+func (l *Logger) activePath(room string) string {
+	return filepath.Join(l.opts.Dir, room+".jsonl")
+}
+
+// sanitizeRoomName maps a room name onto a safe file name without dots, so a room's rotated
+// files can be matched with a simple glob. Other bytes, '_' included, are escaped as "_xx" so
+// different room names never share a file.
+func sanitizeRoomName(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "_%02x", c)
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,147 @@
+package chatlog
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLogger(t *testing.T) {
+	// Test case 1: Empty directory
+	_, err := NewLogger(Options{})
+	assert.Error(t, err)
+
+	// Test case 2: Valid directory
+	l, err := NewLogger(Options{Dir: t.TempDir()})
+	assert.NoError(t, err)
+	assert.NotNil(t, l)
+	l.Close()
+}
+
+func TestLogWritesJSONL(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(Options{Dir: dir})
+	assert.NoError(t, err)
+
+	l.Log(Entry{Username: "alice", Room: "movie night", Message: "hello"})
+	l.Log(Entry{Username: "bob", Room: "movie night", Message: "hi"})
+	l.Close()
+
+	file, err := os.Open(filepath.Join(dir, "movie_20night.jsonl"))
+	assert.NoError(t, err)
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "alice", entries[0].Username)
+	assert.Equal(t, "movie night", entries[0].Room)
+	assert.Equal(t, "hi", entries[1].Message)
+	assert.False(t, entries[0].Timestamp.IsZero())
+}
+
+func TestLogAfterClose(t *testing.T) {
+	l, err := NewLogger(Options{Dir: t.TempDir()})
+	assert.NoError(t, err)
+
+	l.Close()
+	l.Close()
+
+	// must not panic on a closed channel
+	l.Log(Entry{Username: "alice", Room: "room", Message: "late"})
+
+	var nilLogger *Logger
+	nilLogger.Log(Entry{Username: "alice", Room: "room", Message: "nil"})
+}
+
+func TestSizeRotationAndBackups(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(Options{Dir: dir, MaxSize: 120, MaxBackups: 2})
+	assert.NoError(t, err)
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 6; i++ {
+		l.Log(Entry{
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+			Username:  "alice",
+			Room:      "room",
+			Message:   "a message long enough to force a rotation",
+		})
+	}
+	l.Close()
+
+	backups, err := filepath.Glob(filepath.Join(dir, "room.*.jsonl"))
+	assert.NoError(t, err)
+	assert.Len(t, backups, 2)
+
+	_, err = os.Stat(filepath.Join(dir, "room.jsonl"))
+	assert.NoError(t, err)
+}
+
+func TestAgeRotationAndRetention(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(Options{Dir: dir, MaxAge: time.Hour, Retention: 24 * time.Hour})
+	assert.NoError(t, err)
+	l.Close()
+
+	// drive the writer directly so the clock can be controlled
+	now := time.Now()
+	assert.NoError(t, l.write(Entry{Timestamp: now, Username: "alice", Room: "room", Message: "one"}))
+	l.files["room"].opened = now.Add(-2 * time.Hour)
+
+	assert.NoError(t, l.write(Entry{Timestamp: now, Username: "alice", Room: "room", Message: "two"}))
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "room.*.jsonl"))
+	assert.Len(t, backups, 1)
+
+	// an expired backup is removed on the next prune
+	old := now.Add(-48 * time.Hour)
+	assert.NoError(t, os.Chtimes(backups[0], old, old))
+	l.maintain(now)
+
+	backups, _ = filepath.Glob(filepath.Join(dir, "room.*.jsonl"))
+	assert.Len(t, backups, 0)
+}
+
+func TestSanitizeRoomName(t *testing.T) {
+	assert.Equal(t, "room-1", sanitizeRoomName("room-1"))
+	assert.Equal(t, "room_2e1", sanitizeRoomName("room.1"))
+	assert.Equal(t, "_2e_2e_2f_2e_2e_2fetc_2fpasswd", sanitizeRoomName("../../etc/passwd"))
+	assert.Equal(t, "_", sanitizeRoomName(""))
+
+	// rooms that only differ in replaced characters get their own files
+	names := map[string]bool{}
+	for _, room := range []string{"movie night", "movie.night", "movie_night", "movie_20night"} {
+		names[sanitizeRoomName(room)] = true
+	}
+	assert.Len(t, names, 4)
+}
+
+func TestConcurrentDrops(t *testing.T) {
+	// no writer goroutine, so everything after the first entry is dropped
+	l := &Logger{entries: make(chan Entry, 1), files: make(map[string]*roomFile)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Log(Entry{Username: "alice", Room: "room", Message: "hello"})
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 99, l.Dropped())
+}
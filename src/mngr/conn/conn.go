@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/Icey-Glitch/Syncplay-G/mngr/event"
+	"github.com/Icey-Glitch/Syncplay-G/mngr/latency"
 	roomM "github.com/Icey-Glitch/Syncplay-G/mngr/room"
 )
 
@@ -25,6 +26,7 @@ func NewConnectionManager() *ConnectionManager {
 	return &ConnectionManager{
 		rooms:           make(map[string]*roomM.Room),
 		connectionEvent: event.NewEvent(),
+		connToRoom:      make(map[net.Conn]*roomM.Room),
 	}
 }
 
@@ -57,7 +59,10 @@ func (cm *ConnectionManager) AddConnection(username, roomName string, state inte
 			ClientRtt:  float64(0),
 		},
 
-		Owner: cm.rooms[roomName],
+		Owner:     cm.rooms[roomName],
+		BlockList: roomM.NewBlockList(),
+		Latency:   latency.NewTracker(),
+		OnTheFly:  roomM.NewOnTheFly(),
 	}
 
 	room := cm.rooms[roomName]
@@ -108,7 +113,13 @@ func (cm *ConnectionManager) MoveConnection(username string, newRoomName string,
 			ClientRtt:  float64(0),
 		},
 
-		Owner: cm.rooms[newRoomName],
+		Owner:     cm.rooms[newRoomName],
+		BlockList: connection.BlockList,
+		Latency:   connection.Latency,
+		OnTheFly:  connection.OnTheFly,
+
+		SupportsSpeed:      connection.SupportsSpeed,
+		RequestedSpectator: connection.RequestedSpectator,
 	}
 
 	err := newRoom.AddConnection(connection)
@@ -171,6 +182,19 @@ func (cm *ConnectionManager) GetRoomByUsername(username string) *roomM.Room {
 	return nil
 }
 
+// FindConnection returns the connection of a user in any room
+func (cm *ConnectionManager) FindConnection(username string) *roomM.Connection {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	for _, room := range cm.rooms {
+		if connection := room.GetConnectionByUsername(username); connection != nil {
+			return connection
+		}
+	}
+	return nil
+}
+
 func (cm *ConnectionManager) SubscribeToConnections() chan interface{} {
 	return cm.connectionEvent.Subscribe()
 }
@@ -148,6 +148,24 @@ func TestGetRoomByUsername(t *testing.T) {
 	assert.Nil(t, cm.GetRoomByUsername("nonExistentUser"))
 }
 
+func TestFindConnection(t *testing.T) {
+	cm := NewConnectionManager()
+	cm.CreateRoom("testRoom1")
+	cm.CreateRoom("testRoom2")
+
+	_, err := cm.AddConnection("testUser1", "testRoom1", "testState", &net.TCPConn{})
+	assert.NoError(t, err)
+	_, err = cm.AddConnection("testUser2", "testRoom2", "testState", &net.TCPConn{})
+	assert.NoError(t, err)
+
+	found := cm.FindConnection("testUser2")
+	assert.NotNil(t, found)
+	assert.Equal(t, "testRoom2", found.RoomName)
+	assert.NotNil(t, found.BlockList)
+
+	assert.Nil(t, cm.FindConnection("nonExistentUser"))
+}
+
 func TestSubscribeToConnections(t *testing.T) {
 	cm := NewConnectionManager()
 	ch := cm.SubscribeToConnections()
@@ -0,0 +1,132 @@
+package latency
+
+import (
+	"math"
+	"sync"
+)
+
+// movingAverageWeight is the weight of the previous average in the smoothed RTT, as in the reference server
+const movingAverageWeight = 0.85
+
+// Tracker measures the round-trip time of a single connection from echoed latencyCalculation timestamps
+type Tracker struct {
+	mutex sync.RWMutex
+
+	rtt          float64
+	averageRtt   float64
+	jitter       float64
+	forwardDelay float64
+	samples      int
+
+	// the client's own timestamp, echoed back once in the next state
+	clientTimestamp float64
+	arrivalTime     float64
+}
+
+// NewTracker creates a tracker without samples
+func NewTracker() *Tracker {
+	return &Tracker{}
+}
+
+// Receive records a state message that arrived at now. timestamp is the server latencyCalculation
+// the client echoed, senderRtt the RTT the client measured itself and clientTimestamp the client's
+// own clientLatencyCalculation.
+func (t *Tracker) Receive(now float64, timestamp float64, senderRtt float64, clientTimestamp float64) {
+	if t == nil {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if clientTimestamp != 0 {
+		t.clientTimestamp = clientTimestamp
+		t.arrivalTime = now
+	}
+
+	if timestamp == 0 {
+		return
+	}
+
+	rtt := now - timestamp
+	if rtt < 0 || senderRtt < 0 {
+		return
+	}
+
+	t.rtt = rtt
+	if t.samples == 0 {
+		t.averageRtt = rtt
+	}
+	t.averageRtt = t.averageRtt*movingAverageWeight + rtt*(1-movingAverageWeight)
+	t.jitter = t.jitter*movingAverageWeight + math.Abs(rtt-t.averageRtt)*(1-movingAverageWeight)
+	t.samples++
+
+	// half the smoothed RTT, plus whatever the client's own view of the path is missing
+	t.forwardDelay = t.averageRtt / 2
+	if senderRtt < rtt {
+		t.forwardDelay += rtt - senderRtt
+	}
+}
+
+// TakeClientTimestamp returns the client's last timestamp advanced by the time the server held it,
+// so the client can measure its RTT. Each timestamp is returned only once.
+func (t *Tracker) TakeClientTimestamp(now float64) (float64, bool) {
+	if t == nil {
+		return 0, false
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.clientTimestamp == 0 {
+		return 0, false
+	}
+
+	timestamp := t.clientTimestamp + (now - t.arrivalTime)
+	t.clientTimestamp = 0
+	return timestamp, true
+}
+
+// Rtt returns the last measured round-trip time in seconds
+func (t *Tracker) Rtt() float64 {
+	if t == nil {
+		return 0
+	}
+
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.rtt
+}
+
+// AverageRtt returns the smoothed round-trip time in seconds
+func (t *Tracker) AverageRtt() float64 {
+	if t == nil {
+		return 0
+	}
+
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.averageRtt
+}
+
+// Jitter returns the smoothed deviation of the round-trip time in seconds
+func (t *Tracker) Jitter() float64 {
+	if t == nil {
+		return 0
+	}
+
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.jitter
+}
+
+// ForwardDelay returns the estimated one-way delay from the client to the server in seconds
+func (t *Tracker) ForwardDelay() float64 {
+	if t == nil {
+		return 0
+	}
+
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.forwardDelay
+}
@@ -0,0 +1,63 @@
+package latency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReceive(t *testing.T) {
+	tracker := NewTracker()
+
+	// Test case 1: no samples yet
+	assert.Equal(t, 0.0, tracker.Rtt())
+	assert.Equal(t, 0.0, tracker.ForwardDelay())
+
+	// Test case 2: the first sample sets the average
+	tracker.Receive(100.2, 100.0, 0.2, 0)
+	assert.InDelta(t, 0.2, tracker.Rtt(), 1e-9)
+	assert.InDelta(t, 0.2, tracker.AverageRtt(), 1e-9)
+	assert.InDelta(t, 0.1, tracker.ForwardDelay(), 1e-9)
+	assert.InDelta(t, 0.0, tracker.Jitter(), 1e-9)
+
+	// Test case 3: later samples are smoothed and raise the jitter
+	tracker.Receive(101.6, 101.0, 0.2, 0)
+	assert.InDelta(t, 0.6, tracker.Rtt(), 1e-9)
+	assert.InDelta(t, 0.26, tracker.AverageRtt(), 1e-9)
+	assert.Greater(t, tracker.Jitter(), 0.0)
+
+	// Test case 4: the part of the RTT the client has not seen yet is added to the forward delay
+	assert.InDelta(t, 0.13+0.4, tracker.ForwardDelay(), 1e-9)
+
+	// Test case 5: timestamps from the future and negative client RTTs are ignored
+	tracker.Receive(102.0, 103.0, 0.2, 0)
+	tracker.Receive(102.0, 101.9, -1, 0)
+	assert.InDelta(t, 0.6, tracker.Rtt(), 1e-9)
+}
+
+func TestTakeClientTimestamp(t *testing.T) {
+	tracker := NewTracker()
+
+	// Test case 1: nothing to echo
+	_, ok := tracker.TakeClientTimestamp(10)
+	assert.False(t, ok)
+
+	// Test case 2: the timestamp is advanced by the time the server held it
+	tracker.Receive(10, 0, 0, 50)
+	timestamp, ok := tracker.TakeClientTimestamp(10.5)
+	assert.True(t, ok)
+	assert.InDelta(t, 50.5, timestamp, 1e-9)
+
+	// Test case 3: it is only echoed once
+	_, ok = tracker.TakeClientTimestamp(11)
+	assert.False(t, ok)
+}
+
+func TestNilTracker(t *testing.T) {
+	var tracker *Tracker
+
+	tracker.Receive(1, 0.5, 0, 1)
+	assert.Equal(t, 0.0, tracker.Rtt())
+	_, ok := tracker.TakeClientTimestamp(1)
+	assert.False(t, ok)
+}
@@ -0,0 +1,97 @@
+package playlists
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxBookmarks is how many bookmarks one playlist entry can hold
+const maxBookmarks = 50
+
+// Bookmark is a named position in a playlist entry
+type Bookmark struct {
+	Name     string
+	Position float64
+	SetBy    string
+}
+
+// AddBookmark stores a bookmark for the selected playlist entry, replacing one with the same name
+func (pm *PlaylistManager) AddBookmark(name string, position float64, setBy string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("bookmark names can't be empty or contain spaces")
+	}
+	if position < 0 {
+		return fmt.Errorf("bookmark position can't be negative")
+	}
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	entry := pm.bookmarkEntry()
+	bookmarks := pm.bookmarks[entry]
+	for i, bookmark := range bookmarks {
+		if strings.EqualFold(bookmark.Name, name) {
+			bookmarks[i] = Bookmark{Name: name, Position: position, SetBy: setBy}
+			return nil
+		}
+	}
+
+	if len(bookmarks) >= maxBookmarks {
+		return fmt.Errorf("this entry already has %d bookmarks", maxBookmarks)
+	}
+	pm.bookmarks[entry] = append(bookmarks, Bookmark{Name: name, Position: position, SetBy: setBy})
+	return nil
+}
+
+// RemoveBookmark deletes a bookmark of the selected playlist entry
+func (pm *PlaylistManager) RemoveBookmark(name string) (Bookmark, error) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	entry := pm.bookmarkEntry()
+	bookmarks := pm.bookmarks[entry]
+	for i, bookmark := range bookmarks {
+		if strings.EqualFold(bookmark.Name, name) {
+			pm.bookmarks[entry] = append(bookmarks[:i:i], bookmarks[i+1:]...)
+			return bookmark, nil
+		}
+	}
+	return Bookmark{}, fmt.Errorf("there is no bookmark named %s", name)
+}
+
+// FindBookmark returns the bookmark of the selected playlist entry with the given name
+func (pm *PlaylistManager) FindBookmark(name string) (Bookmark, bool) {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	for _, bookmark := range pm.bookmarks[pm.bookmarkEntry()] {
+		if strings.EqualFold(bookmark.Name, name) {
+			return bookmark, true
+		}
+	}
+	return Bookmark{}, false
+}
+
+// GetBookmarks returns the bookmarks of the selected playlist entry, ordered by position
+func (pm *PlaylistManager) GetBookmarks() []Bookmark {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	bookmarks := append([]Bookmark(nil), pm.bookmarks[pm.bookmarkEntry()]...)
+	sort.SliceStable(bookmarks, func(i, j int) bool {
+		return bookmarks[i].Position < bookmarks[j].Position
+	})
+	return bookmarks
+}
+
+// bookmarkEntry returns the name bookmarks are stored under: the selected playlist entry, or ""
+// for rooms that don't use the playlist
+func (pm *PlaylistManager) bookmarkEntry() string {
+	index := pm.Playlist.Index
+	if index == nil || *index < 0 || *index >= len(pm.Playlist.Files) {
+		return ""
+	}
+	return pm.Playlist.Files[*index].Name
+}
@@ -0,0 +1,109 @@
+package playlists
+
+import "fmt"
+
+// allowed playback speeds
+const (
+	MinSpeed = 0.25
+	MaxSpeed = 4.0
+)
+
+// seekDuration is how long (seconds) the clock stays in the seeking state, long enough for
+// every client to receive at least one state with doSeek set
+const seekDuration = 1.0
+
+// ClockState is the state of a room's playback clock
+type ClockState string
+
+const (
+	ClockPaused  ClockState = "paused"
+	ClockPlaying ClockState = "playing"
+	ClockSeeking ClockState = "seeking"
+)
+
+// Clock is a snapshot of a room's playback clock
+type Clock struct {
+	State    ClockState
+	Position float64 // extrapolated to the time of the snapshot
+	Paused   bool
+	DoSeek   bool
+	SetBy    string
+	Speed    float64
+}
+
+// GetClock returns the room's playback clock at now, the only source for outgoing State messages
+func (pm *PlaylistManager) GetClock(now float64) Clock {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	return pm.clockAt(now)
+}
+
+// Seek moves the room to position on behalf of setBy, keeping the pause state
+func (pm *PlaylistManager) Seek(position float64, setBy string, now float64) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	pm.setClock(position, pm.Playlist.Paused, true, setBy, now)
+	pm.stateEvent.Publish(pm.Playlist)
+}
+
+// SetSpeed changes the playback speed of the room on behalf of setBy, keeping the current position
+func (pm *PlaylistManager) SetSpeed(speed float64, setBy string, now float64) error {
+	if speed < MinSpeed || speed > MaxSpeed {
+		return fmt.Errorf("speed %g is outside %g to %g", speed, MinSpeed, MaxSpeed)
+	}
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	clock := pm.clockAt(now)
+	pm.setClock(clock.Position, clock.Paused, false, setBy, now)
+	pm.Playlist.Speed = speed
+
+	pm.stateEvent.Publish(pm.Playlist)
+	return nil
+}
+
+// clockAt extrapolates the clock to now
+func (pm *PlaylistManager) clockAt(now float64) Clock {
+	clock := Clock{
+		State:    ClockPlaying,
+		Position: pm.Playlist.Position,
+		Paused:   pm.Playlist.Paused,
+		SetBy:    pm.Playlist.SetBy,
+		Speed:    pm.Playlist.Speed,
+	}
+	if clock.Speed <= 0 {
+		clock.Speed = 1
+	}
+
+	if !clock.Paused && now > pm.Playlist.PositionTime {
+		clock.Position += (now - pm.Playlist.PositionTime) * clock.Speed
+	}
+	if clock.Position < 0 {
+		clock.Position = 0
+	}
+
+	switch {
+	case pm.Playlist.DoSeek && now-pm.Playlist.seekTime < seekDuration:
+		clock.State = ClockSeeking
+		clock.DoSeek = true
+	case clock.Paused:
+		clock.State = ClockPaused
+	}
+
+	return clock
+}
+
+// setClock sets the clock to position at now
+func (pm *PlaylistManager) setClock(position float64, paused bool, seek bool, setBy string, now float64) {
+	pm.Playlist.Position = position
+	pm.Playlist.PositionTime = now
+	pm.Playlist.Paused = paused
+	pm.Playlist.DoSeek = seek
+	if seek {
+		pm.Playlist.seekTime = now
+	}
+	pm.Playlist.SetBy = setBy
+}
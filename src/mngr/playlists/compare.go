@@ -0,0 +1,178 @@
+package playlists
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// durationTolerance is how far apart two durations (seconds) may be and still count as the same file
+const durationTolerance = 2.5
+
+// hiddenFilename is what clients send instead of the name when they share nothing about it
+const hiddenFilename = "**Hidden filename**"
+
+// filenameStrip matches the characters Syncplay ignores when comparing and hashing file names
+var filenameStrip = regexp.MustCompile(`[-~_\.\[\](): ]`)
+
+// HashFilename hashes a file name the way Syncplay clients do in hashed privacy mode
+func HashFilename(name string) string {
+	isURL := strings.Contains(name, "://")
+	if isURL {
+		name = strings.SplitN(name, "?", 2)[0]
+	}
+	return hashString(stripFilename(name, isURL))
+}
+
+// HashFilesize hashes a file size the way Syncplay clients do in hashed privacy mode
+func HashFilesize(size float64) string {
+	return hashString(formatSize(size))
+}
+
+// hashString returns the first 12 hex characters of the SHA-256 of s
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// stripFilename removes URL escaping and the characters Syncplay ignores from a file name
+func stripFilename(name string, stripURL bool) string {
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	if stripURL {
+		name = name[strings.LastIndex(name, "/")+1:]
+		if unescaped, err := url.PathUnescape(name); err == nil {
+			name = unescaped
+		}
+	}
+	return filenameStrip.ReplaceAllString(name, "")
+}
+
+// formatSize formats a raw size the way Python prints an integer
+func formatSize(size float64) string {
+	return strconv.FormatFloat(size, 'f', -1, 64)
+}
+
+// sameHashed reports whether two values match, with either side possibly hashed by hash
+func sameHashed(a string, b string, hash func(string) string) bool {
+	return a == b || hash(a) == b || a == hash(b)
+}
+
+// sameName compares file names sent raw or hashed
+func sameName(a string, b string) bool {
+	if a == hiddenFilename || b == hiddenFilename {
+		return true
+	}
+	return sameHashed(stripFilename(a, false), stripFilename(b, false), HashFilename)
+}
+
+// sizeString returns the size a user sent, raw or hashed, or "" when it is unknown
+func sizeString(file File) string {
+	if file.Size > 0 {
+		return formatSize(file.Size)
+	}
+	return file.SizeHashed
+}
+
+// FileDifference lists the properties in which two files differ
+type FileDifference struct {
+	Name     bool
+	Size     bool
+	Duration bool
+}
+
+// Any reports whether the files differ at all
+func (d FileDifference) Any() bool {
+	return d.Name || d.Size || d.Duration
+}
+
+// Fields returns the names of the differing properties
+func (d FileDifference) Fields() []string {
+	fields := make([]string, 0, 3)
+	if d.Name {
+		fields = append(fields, "name")
+	}
+	if d.Size {
+		fields = append(fields, "size")
+	}
+	if d.Duration {
+		fields = append(fields, "duration")
+	}
+	return fields
+}
+
+// CompareFiles compares two files. Names and sizes may be raw or hashed on either side;
+// properties one side did not send are not compared.
+func CompareFiles(a File, b File) FileDifference {
+	var diff FileDifference
+
+	if a.Name != "" && b.Name != "" {
+		diff.Name = !sameName(a.Name, b.Name)
+	}
+
+	sizeA, sizeB := sizeString(a), sizeString(b)
+	if sizeA != "" && sizeB != "" {
+		diff.Size = !sameHashed(sizeA, sizeB, hashString)
+	}
+
+	if a.Duration > 0 && b.Duration > 0 {
+		diff.Duration = math.Abs(a.Duration-b.Duration) > durationTolerance
+	}
+
+	return diff
+}
+
+// FileMismatches compares the open files of all users in the room. The file most users have
+// open is taken as the reference, and every user whose file differs from it is returned with
+// what differs. Users without an open file are skipped.
+func (pm *PlaylistManager) FileMismatches() map[string]FileDifference {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	return pm.fileMismatches()
+}
+
+// fileMismatches is FileMismatches without locking
+func (pm *PlaylistManager) fileMismatches() map[string]FileDifference {
+	usernames := make([]string, 0, len(pm.Playlist.Users))
+	for username, user := range pm.Playlist.Users {
+		if user.File != nil {
+			usernames = append(usernames, username)
+		}
+	}
+	sort.Strings(usernames)
+
+	// the reference is the file that matches the most users, ties go to the first username
+	reference := ""
+	best := -1
+	for _, candidate := range usernames {
+		matches := 0
+		for _, other := range usernames {
+			if !CompareFiles(*pm.Playlist.Users[candidate].File, *pm.Playlist.Users[other].File).Any() {
+				matches++
+			}
+		}
+		if matches > best {
+			reference, best = candidate, matches
+		}
+	}
+
+	mismatches := make(map[string]FileDifference)
+	if reference == "" {
+		return mismatches
+	}
+
+	referenceFile := *pm.Playlist.Users[reference].File
+	for _, username := range usernames {
+		if diff := CompareFiles(referenceFile, *pm.Playlist.Users[username].File); diff.Any() {
+			mismatches[username] = diff
+		}
+	}
+	return mismatches
+}
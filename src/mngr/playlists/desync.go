@@ -0,0 +1,82 @@
+package playlists
+
+import "math"
+
+// SetDesyncRange sets how far (seconds) the report of the presenter or a user alone in the room
+// may be off before it moves the room clock
+func (pm *PlaylistManager) SetDesyncRange(desyncRange float64) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	pm.desyncRange = desyncRange
+}
+
+// SetPresenter sets the user whose reports move the room clock whenever they drift out of the
+// desync range, empty when presenter mode is off
+func (pm *PlaylistManager) SetPresenter(username string) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	pm.presenter = username
+}
+
+// CheckDesync compares a user's offset from the room clock with threshold. changed reports
+// whether the user moved out of or back into sync since the last check.
+func (pm *PlaylistManager) CheckDesync(username string, threshold float64, now float64) (offset float64, desynced bool, changed bool) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	user, exists := pm.Playlist.Users[username]
+	if !exists {
+		return 0, false, false
+	}
+
+	desynced = trackable(user, pm.clockAt(now), pm.fileMismatches()) && math.Abs(user.Offset) > threshold
+	changed = desynced != user.Desynced
+
+	user.Desynced = desynced
+	pm.Playlist.Users[username] = user
+	return user.Offset, desynced, changed
+}
+
+// Offsets returns the offset from the room clock of every user whose sync can be judged
+func (pm *PlaylistManager) Offsets(now float64) map[string]float64 {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	clock := pm.clockAt(now)
+	mismatches := pm.fileMismatches()
+
+	offsets := make(map[string]float64)
+	for username, user := range pm.Playlist.Users {
+		if trackable(user, clock, mismatches) {
+			offsets[username] = user.Offset
+		}
+	}
+	return offsets
+}
+
+// trackable reports whether a user's offset says anything about being out of sync. Users without
+// a file, users whose file has a different length than the room's and users that reached the end
+// of a shorter file are left alone, as is everyone while the room is seeking. mismatches are the
+// room's file mismatches, computed once by the caller.
+func trackable(user User, clock Clock, mismatches map[string]FileDifference) bool {
+	if user.File == nil {
+		return false
+	}
+
+	if clock.State == ClockSeeking {
+		return false
+	}
+
+	if mismatches[user.Username].Duration {
+		return false
+	}
+
+	duration := user.File.Duration
+	if duration > 0 && user.Position >= duration-durationTolerance && clock.Position >= duration-durationTolerance {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,346 @@
+package playlists
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxSnapshots is how many old versions of the shared playlist are kept for merging
+const maxSnapshots = 32
+
+// maxHistory is how many playlist edits are kept for /history, /undo and /redo
+const maxHistory = 50
+
+// PlaylistEdit records a single change of the shared playlist
+type PlaylistEdit struct {
+	Author  string
+	Time    time.Time
+	Version int
+	Action  string // edit, undo or redo
+	Before  []string
+	After   []string
+}
+
+// GetVersion returns the version of the shared playlist
+func (pm *PlaylistManager) GetVersion() int {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	return pm.Playlist.Version
+}
+
+// MarkSeen records that a user was sent the current version of the shared playlist
+func (pm *PlaylistManager) MarkSeen(username string) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	pm.seen[username] = pm.Playlist.Version
+}
+
+// InsertFile inserts an entry at position of the playlist as it was at baseVersion.
+// If the playlist changed since then, the entry is placed after the same neighbour.
+func (pm *PlaylistManager) InsertFile(position int, name string, username string, baseVersion int) (int, error) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if name == "" {
+		return pm.Playlist.Version, fmt.Errorf("file name cannot be empty")
+	}
+
+	names := pm.fileNames()
+	if indexOf(names, name) != -1 {
+		return pm.Playlist.Version, fmt.Errorf("%s is already in the playlist", name)
+	}
+
+	at, err := pm.rebasePosition(position, baseVersion, names)
+	if err != nil {
+		return pm.Playlist.Version, err
+	}
+
+	names = append(names[:at], append([]string{name}, names[at:]...)...)
+	pm.commitFiles(pm.filesFromNames(names), username)
+	return pm.Playlist.Version, nil
+}
+
+// MoveFile moves an entry to position of the playlist as it was at baseVersion
+func (pm *PlaylistManager) MoveFile(name string, position int, username string, baseVersion int) (int, error) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	names := pm.fileNames()
+	from := indexOf(names, name)
+	if from == -1 {
+		return pm.Playlist.Version, fmt.Errorf("%s is not in the playlist", name)
+	}
+	names = append(names[:from], names[from+1:]...)
+
+	at, err := pm.rebasePosition(position, baseVersion, names)
+	if err != nil {
+		return pm.Playlist.Version, err
+	}
+
+	names = append(names[:at], append([]string{name}, names[at:]...)...)
+	pm.commitFiles(pm.filesFromNames(names), username)
+	return pm.Playlist.Version, nil
+}
+
+// RemoveFile removes an entry from the playlist. Removing an entry someone else already
+// removed is reported as an error and leaves the playlist unchanged.
+func (pm *PlaylistManager) RemoveFile(name string, username string) (int, error) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	names := pm.fileNames()
+	at := indexOf(names, name)
+	if at == -1 {
+		return pm.Playlist.Version, fmt.Errorf("%s is not in the playlist", name)
+	}
+
+	names = append(names[:at], names[at+1:]...)
+	pm.commitFiles(pm.filesFromNames(names), username)
+	return pm.Playlist.Version, nil
+}
+
+// ApplyFileList merges a full playlist sent by a client into the shared playlist.
+// The list is compared with the version the user last saw, so entries added or removed by
+// others in the meantime are kept or stay removed, while the user's own edits are applied.
+// The order of the user's list wins. It returns the new version.
+func (pm *PlaylistManager) ApplyFileList(names []string, username string) int {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	theirs := unique(names)
+	current := pm.fileNames()
+
+	base, ok := pm.snapshots[pm.seen[username]]
+	if !ok {
+		// too old to merge, the client's list replaces the playlist
+		pm.commitFiles(pm.filesFromNames(theirs), username)
+		return pm.Playlist.Version
+	}
+
+	merged := make([]string, 0, len(theirs)+len(current))
+	for _, name := range theirs {
+		inBase := indexOf(base, name) != -1
+		inCurrent := indexOf(current, name) != -1
+		// drop entries someone else removed since the user's version
+		if inBase && !inCurrent {
+			continue
+		}
+		merged = append(merged, name)
+	}
+
+	// keep entries someone else added since the user's version, after their neighbour
+	for i, name := range current {
+		if indexOf(base, name) != -1 || indexOf(merged, name) != -1 {
+			continue
+		}
+		at := 0
+		if i > 0 {
+			at = indexOf(merged, current[i-1]) + 1
+		}
+		merged = append(merged[:at], append([]string{name}, merged[at:]...)...)
+	}
+
+	pm.commitFiles(pm.filesFromNames(merged), username)
+	return pm.Playlist.Version
+}
+
+// rebasePosition maps a position in the playlist at baseVersion onto names
+func (pm *PlaylistManager) rebasePosition(position int, baseVersion int, names []string) (int, error) {
+	if baseVersion == pm.Playlist.Version {
+		if position < 0 || position > len(names) {
+			return 0, fmt.Errorf("position %d out of range", position)
+		}
+		return position, nil
+	}
+
+	base, ok := pm.snapshots[baseVersion]
+	if !ok {
+		return 0, fmt.Errorf("playlist version %d is no longer available", baseVersion)
+	}
+	if position < 0 || position > len(base) {
+		return 0, fmt.Errorf("position %d out of range", position)
+	}
+
+	// insert after the closest preceding neighbour that still exists
+	for i := position - 1; i >= 0; i-- {
+		if at := indexOf(names, base[i]); at != -1 {
+			return at + 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// GetHistory returns the recorded playlist edits, oldest first
+func (pm *PlaylistManager) GetHistory() []PlaylistEdit {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	history := make([]PlaylistEdit, len(pm.history))
+	copy(history, pm.history)
+	return history
+}
+
+// Undo reverts the most recent edit that has not been undone yet and returns the restored playlist
+func (pm *PlaylistManager) Undo(username string) ([]string, error) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if len(pm.undo) == 0 {
+		return nil, fmt.Errorf("nothing to undo")
+	}
+
+	edit := pm.undo[len(pm.undo)-1]
+	pm.undo = pm.undo[:len(pm.undo)-1]
+	pm.redo = append(pm.redo, edit)
+
+	pm.storeFiles(pm.filesFromNames(edit.Before), username, "undo")
+	return pm.fileNames(), nil
+}
+
+// Redo applies the most recently undone edit again and returns the restored playlist
+func (pm *PlaylistManager) Redo(username string) ([]string, error) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if len(pm.redo) == 0 {
+		return nil, fmt.Errorf("nothing to redo")
+	}
+
+	edit := pm.redo[len(pm.redo)-1]
+	pm.redo = pm.redo[:len(pm.redo)-1]
+	pm.undo = append(pm.undo, edit)
+
+	pm.storeFiles(pm.filesFromNames(edit.After), username, "redo")
+	return pm.fileNames(), nil
+}
+
+// commitFiles stores an edit of the shared playlist and makes it undoable. The caller must hold the lock.
+func (pm *PlaylistManager) commitFiles(files []File, username string) {
+	edit, changed := pm.storeFiles(files, username, "edit")
+	if !changed {
+		return
+	}
+
+	pm.undo = appendBounded(pm.undo, edit)
+	pm.redo = nil
+}
+
+// storeFiles stores a new shared playlist, keeps the selected entry selected if it still
+// exists and records the new version in the history. The caller must hold the lock.
+func (pm *PlaylistManager) storeFiles(files []File, username string, action string) (PlaylistEdit, bool) {
+	before := pm.fileNames()
+
+	var selected string
+	if pm.Playlist.Index != nil && *pm.Playlist.Index < len(pm.Playlist.Files) {
+		selected = pm.Playlist.Files[*pm.Playlist.Index].Name
+	}
+
+	pm.Playlist.Files = files
+	pm.Playlist.User.Username = username
+
+	switch {
+	case len(files) == 0:
+		pm.Playlist.Index = nil
+	case pm.Playlist.Index == nil:
+		index := 0
+		pm.Playlist.Index = &index
+	default:
+		index := *pm.Playlist.Index
+		for i, file := range files {
+			if file.Name == selected {
+				index = i
+				break
+			}
+		}
+		if index >= len(files) {
+			index = len(files) - 1
+		}
+		pm.Playlist.Index = &index
+	}
+
+	pm.Playlist.Version++
+	pm.snapshots[pm.Playlist.Version] = pm.fileNames()
+	delete(pm.snapshots, pm.Playlist.Version-maxSnapshots)
+	pm.seen[username] = pm.Playlist.Version
+
+	edit := PlaylistEdit{
+		Author:  username,
+		Time:    time.Now(),
+		Version: pm.Playlist.Version,
+		Action:  action,
+		Before:  before,
+		After:   pm.fileNames(),
+	}
+	changed := !equalNames(edit.Before, edit.After)
+	if changed {
+		pm.history = appendBounded(pm.history, edit)
+	}
+
+	pm.stateEvent.Publish(pm.Playlist)
+	return edit, changed
+}
+
+func appendBounded(edits []PlaylistEdit, edit PlaylistEdit) []PlaylistEdit {
+	edits = append(edits, edit)
+	if len(edits) > maxHistory {
+		edits = edits[len(edits)-maxHistory:]
+	}
+	return edits
+}
+
+func equalNames(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// filesFromNames builds playlist entries, reusing the metadata of current entries
+func (pm *PlaylistManager) filesFromNames(names []string) []File {
+	files := make([]File, 0, len(names))
+	for _, name := range names {
+		file := File{Name: name}
+		for _, existing := range pm.Playlist.Files {
+			if existing.Name == name {
+				file = existing
+				break
+			}
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+func (pm *PlaylistManager) fileNames() []string {
+	names := make([]string, 0, len(pm.Playlist.Files))
+	for _, file := range pm.Playlist.Files {
+		names = append(names, file.Name)
+	}
+	return names
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func unique(names []string) []string {
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		if indexOf(result, name) == -1 {
+			result = append(result, name)
+		}
+	}
+	return result
+}
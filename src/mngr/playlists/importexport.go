@@ -0,0 +1,217 @@
+package playlists
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	Features "github.com/Icey-Glitch/Syncplay-G/features"
+	"github.com/goccy/go-json"
+)
+
+// ErrPlaylistExists is returned when an export would replace an existing file
+var ErrPlaylistExists = errors.New("playlist file already exists")
+
+// jsonPlaylist is the JSON import/export format
+type jsonPlaylist struct {
+	Files []jsonFile `json:"files"`
+}
+
+type jsonFile struct {
+	Name     string  `json:"name"`
+	Duration float64 `json:"duration,omitempty"`
+	Size     float64 `json:"size,omitempty"`
+}
+
+// ImportPlaylist replaces the shared playlist with the entries of a local M3U, M3U8 or JSON file
+// that rules accept, and returns why the other entries were left out
+func (pm *PlaylistManager) ImportPlaylist(path string, username string, rules TrustRules) ([]error, error) {
+	if strings.Contains(path, "://") {
+		return nil, fmt.Errorf("only local playlist files can be imported")
+	}
+
+	if !Features.GlobalFeatures.SharedPlaylists {
+		return nil, fmt.Errorf("playlists can only be imported when shared playlists are enabled")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open playlist: %w", err)
+	}
+	defer file.Close()
+
+	var files []File
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".m3u", ".m3u8":
+		files, err = ParseM3U(file)
+	case ".json":
+		files, err = ParseJSON(file)
+	default:
+		return nil, fmt.Errorf("unsupported playlist format %s", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	trusted := make([]File, 0, len(files))
+	rejected := make([]error, 0)
+	for _, file := range files {
+		if err := rules.Check(file.Name); err != nil {
+			rejected = append(rejected, err)
+			continue
+		}
+		trusted = append(trusted, file)
+	}
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	// metadata we already know fills in whatever the file leaves out
+	imported := make([]File, 0, len(files))
+	for _, file := range mergeFiles(nil, trusted) {
+		for _, existing := range pm.Playlist.Files {
+			if existing.Name == file.Name {
+				known := existing
+				mergeFileMetadata(&known, file)
+				file = known
+				break
+			}
+		}
+		imported = append(imported, file)
+	}
+
+	pm.commitFiles(imported, username)
+	return rejected, nil
+}
+
+// ExportPlaylist writes the shared playlist to a local M3U, M3U8 or JSON file. An existing file is
+// only replaced when overwrite is set.
+func (pm *PlaylistManager) ExportPlaylist(path string, overwrite bool) error {
+	pm.mutex.RLock()
+	files := make([]File, len(pm.Playlist.Files))
+	copy(files, pm.Playlist.Files)
+	pm.mutex.RUnlock()
+
+	var write func(io.Writer, []File) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".m3u", ".m3u8":
+		write = WriteM3U
+	case ".json":
+		write = WriteJSON
+	default:
+		return fmt.Errorf("unsupported playlist format %s", filepath.Ext(path))
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags |= os.O_EXCL
+	}
+
+	file, err := os.OpenFile(path, flags, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s: %w", filepath.Base(path), ErrPlaylistExists)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create playlist: %w", err)
+	}
+
+	if err = write(file, files); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ParseM3U reads an (extended) M3U playlist. #EXTINF durations and #EXTBYT sizes are kept.
+func ParseM3U(r io.Reader) ([]File, error) {
+	files := make([]File, 0)
+	var pending File
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#EXTINF:"):
+			// #EXTINF:<seconds>,<title>
+			value, _, _ := strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+			if duration, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && duration > 0 {
+				pending.Duration = duration
+			}
+		case strings.HasPrefix(line, "#EXTBYT:"):
+			if size, err := strconv.ParseFloat(strings.TrimPrefix(line, "#EXTBYT:"), 64); err == nil && size > 0 {
+				pending.Size = size
+			}
+		case strings.HasPrefix(line, "#"):
+			continue
+		default:
+			pending.Name = line
+			files = append(files, pending)
+			pending = File{}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read M3U playlist: %w", err)
+	}
+	return files, nil
+}
+
+// WriteM3U writes an extended M3U playlist
+func WriteM3U(w io.Writer, files []File) error {
+	writer := bufio.NewWriter(w)
+	fmt.Fprintln(writer, "#EXTM3U")
+
+	for _, file := range files {
+		duration := -1
+		if file.Duration > 0 {
+			duration = int(file.Duration + 0.5)
+		}
+		fmt.Fprintf(writer, "#EXTINF:%d,%s\n", duration, file.Name)
+		if file.Size > 0 {
+			fmt.Fprintf(writer, "#EXTBYT:%.0f\n", file.Size)
+		}
+		fmt.Fprintln(writer, file.Name)
+	}
+
+	return writer.Flush()
+}
+
+// ParseJSON reads a playlist in the form {"files": [{"name": "...", "duration": 1.0, "size": 1}]}
+func ParseJSON(r io.Reader) ([]File, error) {
+	var playlist jsonPlaylist
+	if err := json.NewDecoder(r).Decode(&playlist); err != nil {
+		return nil, fmt.Errorf("failed to read JSON playlist: %w", err)
+	}
+
+	files := make([]File, 0, len(playlist.Files))
+	for _, file := range playlist.Files {
+		if file.Name == "" {
+			continue
+		}
+		files = append(files, File{Name: file.Name, Duration: file.Duration, Size: file.Size})
+	}
+	return files, nil
+}
+
+// WriteJSON writes a playlist in the format read by ParseJSON
+func WriteJSON(w io.Writer, files []File) error {
+	playlist := jsonPlaylist{Files: make([]jsonFile, 0, len(files))}
+	for _, file := range files {
+		playlist.Files = append(playlist.Files, jsonFile{Name: file.Name, Duration: file.Duration, Size: file.Size})
+	}
+
+	data, err := json.MarshalIndent(playlist, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON playlist: %w", err)
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
@@ -3,6 +3,7 @@ package playlists
 import (
 	"fmt"
 	"math"
+	"math/rand"
 	"sync"
 
 	Features "github.com/Icey-Glitch/Syncplay-G/features"
@@ -17,20 +18,26 @@ type User struct {
 
 	LastMessageAge float64
 
+	// position relative to the room clock at the last report, negative when behind
+	Offset   float64
+	Desynced bool
+
 	File        *File
 	UsrPlaylist []File
+	UsrIndex    *int // selected entry of UsrPlaylist, nil when nothing is selected
 }
 
 type Playlist struct {
-	Files []File
-	Index interface{}
+	Files   []File
+	Index   *int // nil when no entry is selected
+	Version int  // incremented on every change of Files
 
 	SetBy        string
 	Paused       bool
 	DoSeek       bool
 	Position     float64
 	PositionTime float64
-	Ignore       float64
+	Speed        float64 // playback rate, 1 is normal speed
 
 	User struct {
 		Username   string
@@ -40,6 +47,7 @@ type Playlist struct {
 	Users map[string]User
 
 	doSeekTime float64
+	seekTime   float64 // when the last seek was made
 }
 
 type File struct {
@@ -49,16 +57,52 @@ type File struct {
 	Duration   float64
 }
 
+// RepeatMode decides what happens when the end of a file or the playlist is reached
+type RepeatMode string
+
+const (
+	RepeatOff RepeatMode = "off" // stop at the end of the playlist
+	RepeatOne RepeatMode = "one" // play the current entry again
+	RepeatAll RepeatMode = "all" // start over at the end of the playlist
+)
+
 type PlaylistManager struct {
 	Playlist   Playlist
 	mutex      sync.RWMutex
 	stateEvent *event.Event
+
+	// entries already played in shuffle mode, by name
+	played map[string]bool
+
+	// recent versions of the shared playlist, and the version each user last saw
+	snapshots map[int][]string
+	seen      map[string]int
+
+	// edits of the shared playlist, and the edits that can be undone and redone
+	history []PlaylistEdit
+	undo    []PlaylistEdit
+	redo    []PlaylistEdit
+
+	// how far a report may be off before it moves the clock
+	desyncRange float64
+
+	// the user the clock follows in presenter mode
+	presenter string
+
+	// bookmarks by playlist entry name
+	bookmarks map[string][]Bookmark
 }
 
 func NewPlaylistManager() *PlaylistManager {
 	return &PlaylistManager{
-		Playlist:   Playlist{Users: make(map[string]User), Paused: true, DoSeek: false, PositionTime: 0},
+		Playlist:   Playlist{Users: make(map[string]User), Paused: true, DoSeek: false, PositionTime: 0, Speed: 1},
 		stateEvent: event.NewEvent(),
+		played:     make(map[string]bool),
+		bookmarks:  make(map[string][]Bookmark),
+		snapshots:  map[int][]string{0: {}},
+		seen:       make(map[string]int),
+
+		desyncRange: Features.GlobalConfig.DesyncRange,
 	}
 }
 
@@ -99,58 +143,55 @@ func (pm *PlaylistManager) SetUserPlaystate(username string, position float64, p
 	pm.mutex.Lock()
 	defer pm.mutex.Unlock()
 
-	if !Ignore {
-		// check if the possiton is within acceptable range else update the position and set by to inform other users if there is a desync
-		err := pm.SetUsersPosition(position, messageAge)
-		if err != nil {
-			return err
-		}
-
-		if math.Abs(pm.Playlist.Position-position) > Features.GlobalConfig.DesyncRange {
-			pm.Playlist.Position = position
-			pm.Playlist.PositionTime = messageAge
-			pm.Playlist.SetBy = setBy
-		}
+	user, exists := pm.Playlist.Users[username]
+	if !exists {
+		return fmt.Errorf("user %s does not exist in the playlist", username)
+	}
 
-		if doSeek != pm.Playlist.DoSeek {
-			err = pm.SetUsersDoSeek(doSeek, messageAge)
-			if err != nil {
-				return err
-			}
-		}
+	// a report that contradicts the room clock moves it, anything else only updates the user and
+	// keeps the measured offset for desync tracking. Drift only corrects the clock for the presenter
+	// or a user who is alone in the room, for anyone else the clock stays and the user is out of sync.
+	// messageAge is when the command was sent, commands older than the last change are dropped.
+	clock := pm.clockAt(messageAge)
+	user.Offset = position - clock.Position
+	switch {
+	case messageAge < pm.Playlist.PositionTime:
+	case Ignore || doSeek:
+		pm.setClock(position, paused, doSeek, setBy, messageAge)
+		user.Offset = 0
+	case paused != clock.Paused:
+		pm.setClock(position, paused, false, setBy, messageAge)
+		user.Offset = 0
+	case math.Abs(user.Offset) > pm.desyncRange && (username == pm.presenter || len(pm.Playlist.Users) == 1):
+		pm.setClock(position, paused, false, setBy, messageAge)
+		user.Offset = 0
+	}
 
-		if paused != pm.Playlist.Paused {
-			pm.SetUsersPaused(paused)
+	// keep the user's file and playlist, only the playstate changes
+	user.Position = position
+	user.Paused = paused
+	user.DoSeek = doSeek
+	pm.Playlist.Users[username] = user
 
-			err = pm.SetUsersPosition(position, messageAge)
-			if err != nil {
-				return err
-			}
-		}
-	} else {
-		pm.Playlist.Paused = paused
-		pm.Playlist.DoSeek = doSeek
-		pm.Playlist.Position = position
-		pm.Playlist.PositionTime = messageAge
-		pm.Playlist.SetBy = setBy
+	pm.stateEvent.Publish(pm.Playlist.Users[username])
+	return nil
+}
 
-	}
+// RecordUserPlaystate stores a user's playstate and offset from the room clock without moving the clock
+func (pm *PlaylistManager) RecordUserPlaystate(username string, position float64, paused bool, doSeek bool, messageAge float64) error {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
 
-	// TODO: update room paused state if one user unpauses or pause all users if one user pauses
-	// check if user exists
-	_, exists := pm.Playlist.Users[username]
+	user, exists := pm.Playlist.Users[username]
 	if !exists {
 		return fmt.Errorf("user %s does not exist in the playlist", username)
 	}
 
-	pm.Playlist.Users[username] = User{
-		Username: username,
-		Position: position,
-		Paused:   paused,
-		DoSeek:   doSeek,
-	}
-
-	pm.Playlist.SetBy = setBy
+	user.Offset = position - pm.clockAt(messageAge).Position
+	user.Position = position
+	user.Paused = paused
+	user.DoSeek = doSeek
+	pm.Playlist.Users[username] = user
 
 	pm.stateEvent.Publish(pm.Playlist.Users[username])
 	return nil
@@ -171,6 +212,7 @@ func (pm *PlaylistManager) RemoveUserPlaystate(username string) error {
 	}
 
 	delete(pm.Playlist.Users, username)
+	delete(pm.seen, username)
 	pm.stateEvent.Publish(username)
 	return nil
 }
@@ -180,132 +222,318 @@ func (pm *PlaylistManager) AddFile(duration float64, name string, size float64,
 	pm.mutex.Lock()
 	defer pm.mutex.Unlock()
 
-	// check if file already exists
-	for _, file := range pm.Playlist.Files {
-		if file.Name == name {
-			return file, nil
-		}
-	}
-
 	// check if shared playlist is enabled
 	if Features.GlobalFeatures.SharedPlaylists {
-		pm.Playlist.Files = append(pm.Playlist.Files, File{
+		file := File{
 			Size:       size,
 			SizeHashed: Hash,
 			Name:       name,
 			Duration:   duration,
-		})
+		}
+
+		// the shared playlist is only changed through playlistChange, opening a file
+		// just fills in the metadata of a matching entry
+		for i := range pm.Playlist.Files {
+			if pm.Playlist.Files[i].Name == name {
+				mergeFileMetadata(&pm.Playlist.Files[i], file)
+				return pm.Playlist.Files[i], nil
+			}
+		}
+		return file, nil
 
 	} else {
-		// add to the user's playlist in their user object
+		// fill in the metadata of a matching entry in the user's own playlist
 		user, exists := pm.Playlist.Users[User]
 		if !exists {
 			return File{}, fmt.Errorf("user %s does not exist in the playlist", User)
 		}
 
-		user.UsrPlaylist = append(user.UsrPlaylist, File{
+		file := File{
 			Size:       size,
 			SizeHashed: Hash,
 			Name:       name,
 			Duration:   duration,
-		})
-		pm.Playlist.Users[User] = user
+		}
+		for i := range user.UsrPlaylist {
+			if user.UsrPlaylist[i].Name == name {
+				mergeFileMetadata(&user.UsrPlaylist[i], file)
+				pm.Playlist.Users[User] = user
+				return user.UsrPlaylist[i], nil
+			}
+		}
+		return file, nil
+	}
+}
 
-		return user.UsrPlaylist[len(user.UsrPlaylist)-1], nil
+// SetUserFiles replaces a user's own playlist, used when shared playlists are off
+func (pm *PlaylistManager) SetUserFiles(username string, names []string) error {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
 
+	user, exists := pm.Playlist.Users[username]
+	if !exists {
+		return fmt.Errorf("user %s does not exist in the playlist", username)
 	}
 
-	// return the file, and nil error
-	// check if the Files array is empty
-	if len(pm.Playlist.Files) == 0 {
-		return File{}, fmt.Errorf("files array is empty")
+	files := make([]File, 0, len(names))
+	for _, name := range names {
+		files = append(files, File{Name: name})
+	}
+	user.UsrPlaylist = mergeFiles(user.UsrPlaylist, files)
+
+	switch {
+	case len(user.UsrPlaylist) == 0:
+		user.UsrIndex = nil
+	case user.UsrIndex == nil:
+		index := 0
+		user.UsrIndex = &index
+	case *user.UsrIndex >= len(user.UsrPlaylist):
+		index := len(user.UsrPlaylist) - 1
+		user.UsrIndex = &index
 	}
-	return pm.Playlist.Files[len(pm.Playlist.Files)-1], nil
+
+	pm.Playlist.Users[username] = user
+	return nil
 }
 
-// SetLastMessageAge sets the last message age for the user
-func (pm *PlaylistManager) SetLastMessageAge(username string, age float64) {
+// GetUserFileNames returns the entries of a user's own playlist
+func (pm *PlaylistManager) GetUserFileNames(username string) []string {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	user := pm.Playlist.Users[username]
+	names := make([]string, 0, len(user.UsrPlaylist))
+	for _, file := range user.UsrPlaylist {
+		names = append(names, file.Name)
+	}
+	return names
+}
+
+// SetUserIndex selects an entry of a user's own playlist
+func (pm *PlaylistManager) SetUserIndex(username string, index int) error {
 	pm.mutex.Lock()
 	defer pm.mutex.Unlock()
 
-	user := pm.Playlist.Users[username]
-	user.LastMessageAge = age
+	user, exists := pm.Playlist.Users[username]
+	if !exists {
+		return fmt.Errorf("user %s does not exist in the playlist", username)
+	}
+
+	if index < 0 || index >= len(user.UsrPlaylist) {
+		return fmt.Errorf("playlist index %d out of range (%d entries)", index, len(user.UsrPlaylist))
+	}
+
+	user.UsrIndex = &index
 	pm.Playlist.Users[username] = user
+	return nil
 }
 
-// GetLastMessageAge gets the last message age for the user
-func (pm *PlaylistManager) GetLastMessageAge(username string) float64 {
+// GetUserIndex returns the selected entry of a user's own playlist, false if nothing is selected
+func (pm *PlaylistManager) GetUserIndex(username string) (int, bool) {
 	pm.mutex.RLock()
 	defer pm.mutex.RUnlock()
 
-	return pm.Playlist.Users[username].LastMessageAge
+	user := pm.Playlist.Users[username]
+	if user.UsrIndex == nil {
+		return 0, false
+	}
+	return *user.UsrIndex, true
 }
 
-// SetIgnoreInt
-func (pm *PlaylistManager) SetIgnoreInt(ignoreInt float64) {
+// mergeFileMetadata copies the known duration and size of src into dst
+func mergeFileMetadata(dst *File, src File) {
+	if src.Duration > 0 {
+		dst.Duration = src.Duration
+	}
+	if src.Size > 0 {
+		dst.Size = src.Size
+	}
+	if src.SizeHashed != "" {
+		dst.SizeHashed = src.SizeHashed
+	}
+}
+
+// SetFiles replaces the shared playlist with the given entries, keeping the metadata of entries
+// that were already in it. The index is moved back into range if the playlist got shorter.
+func (pm *PlaylistManager) SetFiles(names []string, username string) {
 	pm.mutex.Lock()
 	defer pm.mutex.Unlock()
 
-	pm.Playlist.Ignore = ignoreInt
+	pm.commitFiles(pm.filesFromNames(names), username)
 }
 
-// helper func
-func (pm *PlaylistManager) AddFiles(files []File, User string) {
+// GetFileNames returns the entries of the shared playlist in order
+func (pm *PlaylistManager) GetFileNames() []string {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	return pm.fileNames()
+}
+
+// SetIndex selects a playlist entry, the index must point into the stored playlist
+func (pm *PlaylistManager) SetIndex(index int, username string) error {
 	pm.mutex.Lock()
 	defer pm.mutex.Unlock()
 
-	// check if shared playlist is enabled
-	if Features.GlobalFeatures.SharedPlaylists {
-		// if file doesnt exist in new array then remove it from the playlist, and add new files
-		for i := 0; i < len(pm.Playlist.Files); i++ {
-			found := false
-			for _, file := range files {
-				if file.Name == pm.Playlist.Files[i].Name {
-					found = true
-					break
-				}
-			}
+	if index < 0 || index >= len(pm.Playlist.Files) {
+		return fmt.Errorf("playlist index %d out of range (%d entries)", index, len(pm.Playlist.Files))
+	}
 
-			if !found {
-				pm.Playlist.Files = append(pm.Playlist.Files[:i], pm.Playlist.Files[i+1:]...)
-				i--
-			}
+	pm.Playlist.Index = &index
+	pm.Playlist.User.Username = username
+
+	pm.stateEvent.Publish(pm.Playlist)
+	return nil
+}
+
+// AdvanceAtEndOfFile moves to the next entry once the room position is within threshold seconds
+// of the end of the current entry. The position is reset to the start of the new entry.
+// It returns the new index and whether the room advanced.
+func (pm *PlaylistManager) AdvanceAtEndOfFile(now float64, threshold float64, repeat RepeatMode, shuffle bool, username string) (int, bool) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if pm.Playlist.Index == nil || *pm.Playlist.Index >= len(pm.Playlist.Files) {
+		return 0, false
+	}
+
+	current := *pm.Playlist.Index
+	duration := pm.Playlist.Files[current].Duration
+	if duration <= 0 {
+		// nobody has reported the length of this entry yet
+		return 0, false
+	}
+
+	if pm.clockAt(now).Position < duration-threshold {
+		return 0, false
+	}
+
+	next, ok := pm.nextIndex(current, repeat, shuffle)
+	if !ok {
+		return 0, false
+	}
+
+	pm.Playlist.Index = &next
+	pm.Playlist.User.Username = username
+	pm.setClock(0, pm.Playlist.Paused, false, username, now)
+
+	pm.stateEvent.Publish(pm.Playlist)
+	return next, true
+}
+
+// nextIndex picks the entry that follows current
+func (pm *PlaylistManager) nextIndex(current int, repeat RepeatMode, shuffle bool) (int, bool) {
+	files := pm.Playlist.Files
 
+	if repeat == RepeatOne {
+		return current, true
+	}
+
+	if !shuffle {
+		if current+1 < len(files) {
+			return current + 1, true
+		}
+		if repeat == RepeatAll {
+			return 0, true
 		}
+		return 0, false
+	}
+
+	pm.played[files[current].Name] = true
 
-		for _, file := range files {
-			pm.Playlist.Files = append(pm.Playlist.Files, file)
+	candidates := pm.unplayed(current)
+	if len(candidates) == 0 {
+		if repeat != RepeatAll {
+			return 0, false
+		}
+		// every entry was played, start a new round
+		pm.played = make(map[string]bool)
+		candidates = pm.unplayed(current)
+		if len(candidates) == 0 {
+			return current, true
 		}
+	}
+
+	return candidates[rand.Intn(len(candidates))], true
+}
 
+func (pm *PlaylistManager) unplayed(current int) []int {
+	candidates := make([]int, 0)
+	for i, file := range pm.Playlist.Files {
+		if i != current && !pm.played[file.Name] {
+			candidates = append(candidates, i)
+		}
+	}
+	return candidates
+}
+
+// GetIndex returns the selected playlist entry, false if nothing is selected
+func (pm *PlaylistManager) GetIndex() (int, bool) {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	if pm.Playlist.Index == nil {
+		return 0, false
+	}
+	return *pm.Playlist.Index, true
+}
+
+// SetLastMessageAge sets the last message age for the user
+func (pm *PlaylistManager) SetLastMessageAge(username string, age float64) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	user := pm.Playlist.Users[username]
+	user.LastMessageAge = age
+	pm.Playlist.Users[username] = user
+}
+
+// GetLastMessageAge gets the last message age for the user
+func (pm *PlaylistManager) GetLastMessageAge(username string) float64 {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	return pm.Playlist.Users[username].LastMessageAge
+}
+
+// AddFiles replaces the playlist with files, keeping the order given and the metadata of entries
+// that were already in it. Duplicate names are only added once.
+func (pm *PlaylistManager) AddFiles(files []File, User string) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	// check if shared playlist is enabled
+	if Features.GlobalFeatures.SharedPlaylists {
+		pm.commitFiles(mergeFiles(pm.Playlist.Files, files), User)
 	} else {
 		// Retrieve the User struct from the map
 		userPlaylist := pm.Playlist.Users[User]
+		userPlaylist.UsrPlaylist = mergeFiles(userPlaylist.UsrPlaylist, files)
 
-		// if file doesnt exist in new array then remove it from the playlist
-		for i := 0; i < len(userPlaylist.UsrPlaylist); i++ {
-			found := false
-			for _, file := range files {
-				if file.Name == userPlaylist.UsrPlaylist[i].Name {
-					found = true
-					break
-				}
-			}
+		// Store the updated User struct back in the map
+		pm.Playlist.Users[User] = userPlaylist
+	}
+}
 
-			if !found {
-				// Remove file from user's playlist
-				userPlaylist.UsrPlaylist = append(userPlaylist.UsrPlaylist[:i], userPlaylist.UsrPlaylist[i+1:]...)
-				i--
-			}
+// mergeFiles returns files without duplicates, filling in metadata from existing entries
+func mergeFiles(existing []File, files []File) []File {
+	merged := make([]File, 0, len(files))
+	seen := make(map[string]bool, len(files))
+	for _, file := range files {
+		if seen[file.Name] {
+			continue
 		}
+		seen[file.Name] = true
 
-		// Add new files to the user's playlist
-		for _, file := range files {
-			userPlaylist.UsrPlaylist = append(userPlaylist.UsrPlaylist, file)
+		for _, old := range existing {
+			if old.Name == file.Name {
+				mergeFileMetadata(&file, old)
+				break
+			}
 		}
-
-		// Store the updated User struct back in the map
-		pm.Playlist.Users[User] = userPlaylist
+		merged = append(merged, file)
 	}
+	return merged
 }
 
 // SetUserFile sets the file for the user
@@ -332,9 +560,7 @@ func (pm *PlaylistManager) CalculatePosition(messageAge float64) (position float
 		return pm.Playlist.Position, 0
 	}
 
-	Delta := messageAge - pm.Playlist.PositionTime
-	Position := pm.Playlist.Position + Delta
-	return Position, Delta
+	return pm.clockAt(messageAge).Position, messageAge - pm.Playlist.PositionTime
 }
 
 func (pm *PlaylistManager) GetUserPlaystate(username string) (User, bool) {
@@ -349,6 +575,21 @@ func (pm *PlaylistManager) GetUserPlaystate(username string) (User, bool) {
 	return state, exists
 }
 
+// SetRoomPaused pauses or unpauses the room on behalf of setBy, keeping the current position
+func (pm *PlaylistManager) SetRoomPaused(paused bool, setBy string, now float64) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if pm.Playlist.Paused == paused {
+		return
+	}
+
+	// freeze the position at the moment of pausing
+	pm.setClock(pm.clockAt(now).Position, paused, false, setBy, now)
+
+	pm.stateEvent.Publish(pm.Playlist)
+}
+
 // SetUsersDoSeek sets all users in the playlist to doSeek
 func (pm *PlaylistManager) SetUsersDoSeek(doSeek bool, age float64) error {
 	if age > pm.Playlist.doSeekTime { // only update if the new age is greater
@@ -1,6 +1,10 @@
 package playlists
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	Features "github.com/Icey-Glitch/Syncplay-G/features"
@@ -215,3 +219,721 @@ func TestAddFile(t *testing.T) {
 	assert.NoError(t, err)
 
 }
+
+func TestSetRoomPaused(t *testing.T) {
+	pm := NewPlaylistManager()
+	pm.Playlist.Position = 10
+
+	// Test case 1: unpause keeps the position and records who did it
+	pm.SetRoomPaused(false, "server", 100)
+	assert.False(t, pm.GetUserPauseState())
+	assert.Equal(t, "server", pm.Playlist.SetBy)
+	assert.Equal(t, float64(10), pm.Playlist.Position)
+
+	// Test case 2: pausing freezes the position at the elapsed time
+	pm.SetRoomPaused(true, "testUser", 105)
+	assert.True(t, pm.GetUserPauseState())
+	assert.Equal(t, float64(15), pm.Playlist.Position)
+	assert.Equal(t, "testUser", pm.Playlist.SetBy)
+}
+
+func TestSetFiles(t *testing.T) {
+	pm := NewPlaylistManager()
+
+	// Test case 1: empty playlist has no index
+	pm.SetFiles(nil, "testUser")
+	_, ok := pm.GetIndex()
+	assert.False(t, ok)
+
+	// Test case 2: new playlist selects the first entry
+	pm.SetFiles([]string{"a.mkv", "b.mkv", "c.mkv"}, "testUser")
+	assert.Equal(t, []string{"a.mkv", "b.mkv", "c.mkv"}, pm.GetFileNames())
+	index, ok := pm.GetIndex()
+	assert.True(t, ok)
+	assert.Equal(t, 0, index)
+	assert.Equal(t, "testUser", pm.Playlist.User.Username)
+
+	// Test case 3: metadata of kept entries survives a change
+	Features.GlobalFeatures.SharedPlaylists = true
+	_, err := pm.AddFile(120, "c.mkv", 1000, "testUser", "")
+	assert.NoError(t, err)
+	assert.NoError(t, pm.SetIndex(2, "testUser"))
+
+	pm.SetFiles([]string{"c.mkv", "d.mkv"}, "otherUser")
+	assert.Equal(t, float64(120), pm.Playlist.Files[0].Duration)
+	assert.Equal(t, float64(1000), pm.Playlist.Files[0].Size)
+
+	// Test case 4: the index follows the selected entry
+	index, _ = pm.GetIndex()
+	assert.Equal(t, 0, index)
+
+	// Test case 5: the index is moved back into range when the entry is gone
+	assert.NoError(t, pm.SetIndex(1, "testUser"))
+	pm.SetFiles([]string{"c.mkv"}, "testUser")
+	index, _ = pm.GetIndex()
+	assert.Equal(t, 0, index)
+}
+
+func TestAddFileDoesNotChangeSharedPlaylist(t *testing.T) {
+	Features.GlobalFeatures.SharedPlaylists = true
+	pm := NewPlaylistManager()
+	pm.SetFiles([]string{"a.mkv"}, "testUser")
+
+	file, err := pm.AddFile(60, "local.mkv", 10, "testUser", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "local.mkv", file.Name)
+	assert.Equal(t, []string{"a.mkv"}, pm.GetFileNames())
+}
+
+func TestSetIndex(t *testing.T) {
+	pm := NewPlaylistManager()
+
+	// Test case 1: no playlist
+	assert.Error(t, pm.SetIndex(0, "testUser"))
+
+	pm.SetFiles([]string{"a.mkv", "b.mkv"}, "testUser")
+
+	// Test case 2: out of range
+	assert.Error(t, pm.SetIndex(2, "testUser"))
+	assert.Error(t, pm.SetIndex(-1, "testUser"))
+
+	// Test case 3: valid index
+	assert.NoError(t, pm.SetIndex(1, "otherUser"))
+	index, ok := pm.GetIndex()
+	assert.True(t, ok)
+	assert.Equal(t, 1, index)
+	assert.Equal(t, "otherUser", pm.Playlist.User.Username)
+}
+
+func TestAdvanceAtEndOfFile(t *testing.T) {
+	Features.GlobalFeatures.SharedPlaylists = true
+	pm := NewPlaylistManager()
+	pm.SetFiles([]string{"a.mkv", "b.mkv"}, "testUser")
+
+	// Test case 1: unknown duration never ends
+	_, advanced := pm.AdvanceAtEndOfFile(0, 1, RepeatOff, false, "server")
+	assert.False(t, advanced)
+
+	_, err := pm.AddFile(100, "a.mkv", 0, "testUser", "")
+	assert.NoError(t, err)
+	_, err = pm.AddFile(50, "b.mkv", 0, "testUser", "")
+	assert.NoError(t, err)
+
+	// Test case 2: still playing
+	pm.Playlist.Position = 90
+	_, advanced = pm.AdvanceAtEndOfFile(0, 1, RepeatOff, false, "server")
+	assert.False(t, advanced)
+
+	// Test case 3: extrapolated position reaches the end
+	pm.SetRoomPaused(false, "testUser", 0)
+	index, advanced := pm.AdvanceAtEndOfFile(9.5, 1, RepeatOff, false, "server")
+	assert.True(t, advanced)
+	assert.Equal(t, 1, index)
+	assert.Equal(t, float64(0), pm.Playlist.Position)
+
+	// Test case 4: end of playlist without repeat
+	pm.Playlist.Position = 50
+	_, advanced = pm.AdvanceAtEndOfFile(9.5, 1, RepeatOff, false, "server")
+	assert.False(t, advanced)
+
+	// Test case 5: repeat all wraps around
+	index, advanced = pm.AdvanceAtEndOfFile(9.5, 1, RepeatAll, false, "server")
+	assert.True(t, advanced)
+	assert.Equal(t, 0, index)
+
+	// Test case 6: repeat one stays on the entry
+	pm.Playlist.Position = 100
+	index, advanced = pm.AdvanceAtEndOfFile(9.5, 1, RepeatOne, false, "server")
+	assert.True(t, advanced)
+	assert.Equal(t, 0, index)
+}
+
+func TestAdvanceShuffle(t *testing.T) {
+	Features.GlobalFeatures.SharedPlaylists = true
+	pm := NewPlaylistManager()
+	pm.SetFiles([]string{"a.mkv", "b.mkv", "c.mkv"}, "testUser")
+	for _, name := range []string{"a.mkv", "b.mkv", "c.mkv"} {
+		_, err := pm.AddFile(10, name, 0, "testUser", "")
+		assert.NoError(t, err)
+	}
+
+	// every entry is played once before the shuffle stops
+	seen := map[int]bool{0: true}
+	for i := 0; i < 2; i++ {
+		pm.Playlist.Position = 10
+		index, advanced := pm.AdvanceAtEndOfFile(0, 1, RepeatOff, true, "server")
+		assert.True(t, advanced)
+		assert.False(t, seen[index])
+		seen[index] = true
+	}
+
+	pm.Playlist.Position = 10
+	_, advanced := pm.AdvanceAtEndOfFile(0, 1, RepeatOff, true, "server")
+	assert.False(t, advanced)
+
+	// repeat all starts a new round
+	_, advanced = pm.AdvanceAtEndOfFile(0, 1, RepeatAll, true, "server")
+	assert.True(t, advanced)
+}
+
+func TestAddFilesNoDuplicates(t *testing.T) {
+	Features.GlobalFeatures.SharedPlaylists = true
+	pm := NewPlaylistManager()
+
+	pm.AddFiles([]File{{Name: "a.mkv"}, {Name: "b.mkv"}}, "testUser")
+	pm.AddFiles([]File{{Name: "b.mkv"}, {Name: "a.mkv"}, {Name: "a.mkv"}, {Name: "c.mkv"}}, "testUser")
+
+	assert.Equal(t, []string{"b.mkv", "a.mkv", "c.mkv"}, pm.GetFileNames())
+}
+
+func TestPlaylistOperations(t *testing.T) {
+	pm := NewPlaylistManager()
+	assert.Equal(t, 0, pm.GetVersion())
+
+	// Test case 1: insert at the current version
+	version, err := pm.InsertFile(0, "a.mkv", "alice", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, version)
+	version, err = pm.InsertFile(1, "c.mkv", "alice", version)
+	assert.NoError(t, err)
+
+	// Test case 2: duplicates and bad positions are rejected
+	_, err = pm.InsertFile(0, "a.mkv", "alice", version)
+	assert.Error(t, err)
+	_, err = pm.InsertFile(5, "x.mkv", "alice", version)
+	assert.Error(t, err)
+
+	// Test case 3: insert based on an older version lands after the same neighbour
+	_, err = pm.InsertFile(0, "z.mkv", "bob", version)
+	assert.NoError(t, err)
+	_, err = pm.InsertFile(1, "b.mkv", "alice", version)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"z.mkv", "a.mkv", "b.mkv", "c.mkv"}, pm.GetFileNames())
+
+	// Test case 4: move
+	_, err = pm.MoveFile("z.mkv", 3, "alice", pm.GetVersion())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.mkv", "b.mkv", "c.mkv", "z.mkv"}, pm.GetFileNames())
+
+	// Test case 5: remove, twice
+	_, err = pm.RemoveFile("b.mkv", "alice")
+	assert.NoError(t, err)
+	_, err = pm.RemoveFile("b.mkv", "bob")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"a.mkv", "c.mkv", "z.mkv"}, pm.GetFileNames())
+}
+
+func TestApplyFileListMergesConcurrentEdits(t *testing.T) {
+	pm := NewPlaylistManager()
+	pm.SetFiles([]string{"a.mkv", "b.mkv", "c.mkv"}, "alice")
+	pm.MarkSeen("alice")
+	pm.MarkSeen("bob")
+
+	// alice adds d.mkv, bob has not seen it yet when he removes a.mkv
+	pm.ApplyFileList([]string{"a.mkv", "b.mkv", "c.mkv", "d.mkv"}, "alice")
+	pm.ApplyFileList([]string{"b.mkv", "c.mkv"}, "bob")
+	assert.Equal(t, []string{"b.mkv", "c.mkv", "d.mkv"}, pm.GetFileNames())
+
+	// carol still has c.mkv, which alice removed in the meantime, and reorders
+	pm.MarkSeen("carol")
+	pm.ApplyFileList([]string{"b.mkv", "d.mkv"}, "alice")
+	pm.ApplyFileList([]string{"d.mkv", "c.mkv", "b.mkv", "e.mkv"}, "carol")
+	assert.Equal(t, []string{"d.mkv", "b.mkv", "e.mkv"}, pm.GetFileNames())
+}
+
+func TestUndoRedo(t *testing.T) {
+	pm := NewPlaylistManager()
+
+	// Test case 1: nothing to undo or redo
+	_, err := pm.Undo("alice")
+	assert.Error(t, err)
+	_, err = pm.Redo("alice")
+	assert.Error(t, err)
+
+	pm.SetFiles([]string{"a.mkv", "b.mkv"}, "alice")
+	pm.SetFiles([]string{}, "bob")
+
+	// Test case 2: undo restores the cleared playlist
+	files, err := pm.Undo("alice")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.mkv", "b.mkv"}, files)
+	assert.Equal(t, files, pm.GetFileNames())
+
+	// Test case 3: redo clears it again
+	files, err = pm.Redo("alice")
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+
+	// Test case 4: a new edit drops the redo stack
+	_, err = pm.Undo("alice")
+	assert.NoError(t, err)
+	pm.SetFiles([]string{"c.mkv"}, "carol")
+	_, err = pm.Redo("alice")
+	assert.Error(t, err)
+
+	// Test case 5: history records author and action of every change
+	history := pm.GetHistory()
+	assert.Len(t, history, 6)
+	assert.Equal(t, "bob", history[1].Author)
+	assert.Equal(t, "undo", history[2].Action)
+	assert.Equal(t, "redo", history[3].Action)
+	assert.Equal(t, "carol", history[5].Author)
+	assert.Equal(t, []string{"c.mkv"}, history[5].After)
+}
+
+func TestHistoryIsBounded(t *testing.T) {
+	pm := NewPlaylistManager()
+	for i := 0; i < maxHistory+10; i++ {
+		pm.SetFiles([]string{fmt.Sprintf("%d.mkv", i)}, "alice")
+	}
+
+	assert.Len(t, pm.GetHistory(), maxHistory)
+	assert.Len(t, pm.undo, maxHistory)
+}
+
+func TestParseM3U(t *testing.T) {
+	m3u := "#EXTM3U\n#EXTINF:596,Big Buck Bunny\n#EXTBYT:220514438\nBigBuckBunny.avi\n\nhttps://example.com/video.mp4\n"
+
+	files, err := ParseM3U(strings.NewReader(m3u))
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+	assert.Equal(t, File{Name: "BigBuckBunny.avi", Duration: 596, Size: 220514438}, files[0])
+	assert.Equal(t, File{Name: "https://example.com/video.mp4"}, files[1])
+}
+
+func TestImportExportPlaylist(t *testing.T) {
+	Features.GlobalFeatures.SharedPlaylists = true
+	dir := t.TempDir()
+
+	pm := NewPlaylistManager()
+	pm.SetFiles([]string{"a.mkv", "b.mkv"}, "alice")
+	_, err := pm.AddFile(120.5, "a.mkv", 1000, "alice", "")
+	assert.NoError(t, err)
+
+	for _, name := range []string{"list.m3u8", "list.json"} {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, pm.ExportPlaylist(path, false))
+
+		imported := NewPlaylistManager()
+		rejected, err := imported.ImportPlaylist(path, "bob", TrustRules{})
+		assert.NoError(t, err)
+		assert.Empty(t, rejected)
+		assert.Equal(t, []string{"a.mkv", "b.mkv"}, imported.GetFileNames())
+		assert.Equal(t, float64(1000), imported.Playlist.Files[0].Size)
+		assert.Equal(t, "bob", imported.Playlist.User.Username)
+	}
+
+	// Test case: unsupported formats and remote files
+	assert.Error(t, pm.ExportPlaylist(filepath.Join(dir, "list.txt"), false))
+	_, err = pm.ImportPlaylist("http://example.com/list.m3u", "bob", TrustRules{})
+	assert.Error(t, err)
+	_, err = pm.ImportPlaylist(filepath.Join(dir, "missing.json"), "bob", TrustRules{})
+	assert.Error(t, err)
+
+	// Test case: existing files are only replaced when asked to
+	err = pm.ExportPlaylist(filepath.Join(dir, "list.json"), false)
+	assert.ErrorIs(t, err, ErrPlaylistExists)
+	assert.NoError(t, pm.ExportPlaylist(filepath.Join(dir, "list.json"), true))
+
+	// Test case: entries the trust rules don't accept are left out
+	path := filepath.Join(dir, "untrusted.m3u")
+	assert.NoError(t, os.WriteFile(path, []byte("a.mkv\njavascript:alert(1)\nhttps://example.com/b.mp4\n"), 0o644))
+	rejected, err := pm.ImportPlaylist(path, "bob", TrustRules{Schemes: []string{"https"}})
+	assert.NoError(t, err)
+	assert.Len(t, rejected, 1)
+	assert.Equal(t, []string{"a.mkv", "https://example.com/b.mp4"}, pm.GetFileNames())
+}
+
+func TestUserPlaylist(t *testing.T) {
+	Features.GlobalFeatures.SharedPlaylists = false
+	defer func() { Features.GlobalFeatures.SharedPlaylists = true }()
+
+	pm := NewPlaylistManager()
+	assert.Error(t, pm.SetUserFiles("alice", []string{"a.mkv"}))
+
+	assert.NoError(t, pm.CreateUserPlaystate("alice"))
+	assert.NoError(t, pm.CreateUserPlaystate("bob"))
+
+	// Test case 1: each user has their own playlist and index
+	assert.NoError(t, pm.SetUserFiles("alice", []string{"a.mkv", "b.mkv", "a.mkv"}))
+	assert.Equal(t, []string{"a.mkv", "b.mkv"}, pm.GetUserFileNames("alice"))
+	assert.Empty(t, pm.GetUserFileNames("bob"))
+	assert.Empty(t, pm.GetFileNames())
+
+	index, ok := pm.GetUserIndex("alice")
+	assert.True(t, ok)
+	assert.Equal(t, 0, index)
+	_, ok = pm.GetUserIndex("bob")
+	assert.False(t, ok)
+
+	// Test case 2: index validation against the user's playlist
+	assert.NoError(t, pm.SetUserIndex("alice", 1))
+	assert.Error(t, pm.SetUserIndex("alice", 2))
+	assert.Error(t, pm.SetUserIndex("bob", 0))
+
+	// Test case 3: opening a file fills in metadata without adding entries
+	_, err := pm.AddFile(60, "b.mkv", 100, "alice", "")
+	assert.NoError(t, err)
+	_, err = pm.AddFile(60, "c.mkv", 100, "alice", "")
+	assert.NoError(t, err)
+	user, _ := pm.GetUserObject("alice")
+	assert.Len(t, user.UsrPlaylist, 2)
+	assert.Equal(t, float64(60), user.UsrPlaylist[1].Duration)
+
+	// Test case 4: playstate updates keep the user's playlist and file
+	assert.NoError(t, pm.SetUserFile("alice", File{Name: "b.mkv"}))
+	assert.NoError(t, pm.SetUserPlaystate("alice", 5, false, false, "alice", 1, false))
+	user, _ = pm.GetUserObject("alice")
+	assert.Len(t, user.UsrPlaylist, 2)
+	assert.NotNil(t, user.File)
+	assert.Equal(t, float64(5), user.Position)
+}
+
+func TestTrustRules(t *testing.T) {
+	rules := TrustRules{
+		Domains: []string{"youtube.com", "*.vimeo.com"},
+		Schemes: []string{"https"},
+	}
+
+	// Test case 1: file names are always accepted
+	assert.NoError(t, rules.Check("BigBuckBunny.avi"))
+
+	// Test case 2: trusted domains and their subdomains
+	assert.NoError(t, rules.Check("https://youtube.com/watch?v=0TVdTvWzr-A"))
+	assert.NoError(t, rules.Check("https://www.YouTube.com/watch?v=0TVdTvWzr-A"))
+	assert.NoError(t, rules.Check("https://player.vimeo.com:443/video/1"))
+
+	// Test case 3: untrusted domains, look-alikes and schemes
+	assert.Error(t, rules.Check("https://evil.com/video.mp4"))
+	assert.Error(t, rules.Check("https://notyoutube.com/video.mp4"))
+	assert.Error(t, rules.Check("http://youtube.com/watch?v=0TVdTvWzr-A"))
+	assert.Error(t, rules.Check("file:///etc/passwd"))
+	assert.Error(t, rules.Check("file:/etc/passwd"))
+	assert.Error(t, rules.Check("javascript:alert(1)"))
+	assert.Error(t, rules.Check("magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a"))
+
+	// Test case 3b: file names that look like they have a scheme
+	assert.NoError(t, rules.Check("C:\\Videos\\BigBuckBunny.avi"))
+	assert.NoError(t, rules.Check("Movie: Part 2.mkv"))
+	assert.NoError(t, rules.Check("100% done.mkv"))
+	assert.NoError(t, rules.Check("Episode:1.mkv"))
+	assert.NoError(t, rules.Check("Show:Part2.mp4"))
+
+	// Test case 4: filter keeps the order of accepted entries
+	accepted, rejected := rules.Filter([]string{"a.mkv", "https://evil.com/x", "https://youtube.com/y"})
+	assert.Equal(t, []string{"a.mkv", "https://youtube.com/y"}, accepted)
+	assert.Len(t, rejected, 1)
+
+	// Test case 5: empty rules accept every URL
+	assert.NoError(t, TrustRules{}.Check("ftp://anything.example/file"))
+
+	// Test case 6: the default allowlist only accepts web URLs
+	defaults := TrustRules{Schemes: []string{"http", "https"}}
+	assert.NoError(t, defaults.Check("http://example.com/video.mp4"))
+	assert.Error(t, defaults.Check("javascript:alert(1)"))
+	assert.Error(t, defaults.Check("magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a"))
+	assert.Error(t, defaults.Check("file:/etc/passwd"))
+	assert.NoError(t, defaults.Check("Episode:1.mkv"))
+	assert.NoError(t, defaults.Check("Show:Part2.mp4"))
+}
+
+func TestCompareFiles(t *testing.T) {
+	file := File{Name: "BigBuckBunny.avi", Size: 220514438, Duration: 596.458}
+
+	// Test case 1: identical files, small duration differences are tolerated
+	assert.False(t, CompareFiles(file, file).Any())
+	assert.False(t, CompareFiles(file, File{Name: "BigBuckBunny.avi", Size: 220514438, Duration: 596.0}).Any())
+
+	// Test case 2: each property is reported separately
+	diff := CompareFiles(file, File{Name: "Other.avi", Size: 1, Duration: 120})
+	assert.Equal(t, []string{"name", "size", "duration"}, diff.Fields())
+
+	// Test case 3: properties a user did not send are not compared
+	assert.False(t, CompareFiles(file, File{Name: "BigBuckBunny.avi"}).Any())
+
+	// Test case 4: hashed sizes are compared with each other
+	assert.True(t, CompareFiles(File{SizeHashed: "44657bd3c1bd"}, File{SizeHashed: "000000000000"}).Size)
+}
+
+func TestFileMismatches(t *testing.T) {
+	pm := NewPlaylistManager()
+	for _, username := range []string{"alice", "bob", "carol", "dave"} {
+		assert.NoError(t, pm.CreateUserPlaystate(username))
+	}
+
+	// Test case 1: nobody has a file open
+	assert.Empty(t, pm.FileMismatches())
+
+	// Test case 2: the majority file is the reference
+	file := File{Name: "BigBuckBunny.avi", Size: 220514438, Duration: 596.458}
+	assert.NoError(t, pm.SetUserFile("alice", file))
+	assert.NoError(t, pm.SetUserFile("bob", file))
+	assert.NoError(t, pm.SetUserFile("carol", File{Name: "BigBuckBunny.avi", Size: 220514438, Duration: 300}))
+
+	mismatches := pm.FileMismatches()
+	assert.Len(t, mismatches, 1)
+	assert.Equal(t, []string{"duration"}, mismatches["carol"].Fields())
+
+	// Test case 3: users without a file are not flagged
+	_, flagged := mismatches["dave"]
+	assert.False(t, flagged)
+}
+
+func TestFileHashing(t *testing.T) {
+	// Test case 1: hashes match the ones Syncplay clients send
+	assert.Equal(t, "6fa13ad43fea", HashFilename("BigBuckBunny.avi"))
+	assert.Equal(t, "44657bd3c1bd", HashFilesize(220514438))
+
+	// Test case 2: URLs are hashed by their last path segment without the query
+	assert.Equal(t, HashFilename("BigBuckBunny.avi"), HashFilename("https://example.com/videos/BigBuckBunny.avi?t=10"))
+
+	// Test case 3: raw and hashed senders with the same file match
+	raw := File{Name: "BigBuckBunny.avi", Size: 220514438, Duration: 596.458}
+	hashed := File{Name: "6fa13ad43fea", SizeHashed: "44657bd3c1bd", Duration: 596.0}
+	assert.False(t, CompareFiles(raw, hashed).Any())
+	assert.False(t, CompareFiles(hashed, raw).Any())
+
+	// Test case 4: a different raw file is still detected
+	other := File{Name: "Sintel.mkv", Size: 1, Duration: 596.0}
+	assert.Equal(t, []string{"name", "size"}, CompareFiles(other, hashed).Fields())
+
+	// Test case 5: hidden names and ignored characters do not cause mismatches
+	assert.False(t, CompareFiles(File{Name: hiddenFilename}, raw).Any())
+	assert.False(t, CompareFiles(File{Name: "Big_Buck Bunny.avi"}, raw).Any())
+}
+
+func TestClock(t *testing.T) {
+	Features.SetConfig(*Features.NewConfig())
+	pm := NewPlaylistManager()
+	assert.NoError(t, pm.CreateUserPlaystate("alice"))
+
+	// Test case 1: a new room is paused at the start
+	clock := pm.GetClock(100)
+	assert.Equal(t, ClockPaused, clock.State)
+	assert.Equal(t, float64(0), clock.Position)
+
+	// Test case 2: unpausing starts the clock, the position is extrapolated from wall time
+	assert.NoError(t, pm.SetUserPlaystate("alice", 10, false, false, "alice", 100, false))
+	clock = pm.GetClock(104)
+	assert.Equal(t, ClockPlaying, clock.State)
+	assert.InDelta(t, 14, clock.Position, 1e-9)
+	assert.Equal(t, "alice", clock.SetBy)
+
+	// Test case 3: reports close to the clock do not move it or change who set it
+	assert.NoError(t, pm.SetUserPlaystate("alice", 14.05, false, false, "Nobody", 104, false))
+	assert.Equal(t, "alice", pm.GetClock(104).SetBy)
+
+	// Test case 4: a seek is announced for a short time only
+	assert.NoError(t, pm.SetUserPlaystate("alice", 60, false, true, "alice", 105, false))
+	clock = pm.GetClock(105.5)
+	assert.Equal(t, ClockSeeking, clock.State)
+	assert.True(t, clock.DoSeek)
+	assert.InDelta(t, 60.5, clock.Position, 1e-9)
+	assert.False(t, pm.GetClock(107).DoSeek)
+
+	// Test case 5: pausing freezes the position
+	pm.SetRoomPaused(true, "server", 110)
+	clock = pm.GetClock(200)
+	assert.Equal(t, ClockPaused, clock.State)
+	assert.InDelta(t, 65, clock.Position, 1e-9)
+
+	// Test case 6: a server seek keeps the pause state
+	pm.Seek(0, "server", 201)
+	clock = pm.GetClock(201)
+	assert.True(t, clock.Paused)
+	assert.True(t, clock.DoSeek)
+	assert.Equal(t, float64(0), clock.Position)
+}
+
+func TestClockDropsOlderCommands(t *testing.T) {
+	Features.SetConfig(*Features.NewConfig())
+	pm := NewPlaylistManager()
+	assert.NoError(t, pm.CreateUserPlaystate("alice"))
+	assert.NoError(t, pm.CreateUserPlaystate("bob"))
+
+	// alice pauses at 20.0, bob's unpause was sent before that but arrives later
+	assert.NoError(t, pm.SetUserPlaystate("alice", 30, true, false, "alice", 20.0, true))
+	assert.NoError(t, pm.SetUserPlaystate("bob", 29, false, false, "bob", 19.9, true))
+
+	clock := pm.GetClock(21)
+	assert.True(t, clock.Paused)
+	assert.Equal(t, "alice", clock.SetBy)
+	assert.Equal(t, float64(30), clock.Position)
+
+	// bob's own state is still recorded
+	bob, _ := pm.GetUserObject("bob")
+	assert.False(t, bob.Paused)
+}
+
+func TestClockSpeed(t *testing.T) {
+	Features.SetConfig(*Features.NewConfig())
+	pm := NewPlaylistManager()
+	assert.NoError(t, pm.CreateUserPlaystate("alice"))
+
+	// Test case 1: rooms play at normal speed
+	assert.Equal(t, float64(1), pm.GetClock(0).Speed)
+
+	// Test case 2: the speed is applied from the moment it changes
+	assert.NoError(t, pm.SetUserPlaystate("alice", 10, false, false, "alice", 100, false))
+	assert.NoError(t, pm.SetSpeed(2, "alice", 104))
+	clock := pm.GetClock(106)
+	assert.Equal(t, float64(2), clock.Speed)
+	assert.InDelta(t, 18, clock.Position, 1e-9)
+	assert.Equal(t, "alice", clock.SetBy)
+
+	// Test case 3: a paused room keeps its position and speed
+	pm.SetRoomPaused(true, "server", 106)
+	clock = pm.GetClock(120)
+	assert.InDelta(t, 18, clock.Position, 1e-9)
+	assert.Equal(t, float64(2), clock.Speed)
+
+	// Test case 4: speeds outside the allowed range are rejected
+	assert.Error(t, pm.SetSpeed(0, "alice", 120))
+	assert.Error(t, pm.SetSpeed(MaxSpeed+1, "alice", 120))
+	assert.Equal(t, float64(2), pm.GetClock(120).Speed)
+}
+
+func TestCheckDesync(t *testing.T) {
+	Features.SetConfig(*Features.NewConfig())
+	pm := NewPlaylistManager()
+	for _, username := range []string{"alice", "bob"} {
+		assert.NoError(t, pm.CreateUserPlaystate(username))
+		assert.NoError(t, pm.SetUserFile(username, File{Name: "a.mkv", Duration: 600}))
+	}
+
+	// alice starts playback, bob reports 4 seconds behind
+	assert.NoError(t, pm.SetUserPlaystate("alice", 100, false, false, "alice", 10, true))
+	assert.NoError(t, pm.SetUserPlaystate("bob", 101, false, false, "bob", 15, false))
+
+	// Test case 1: bob is flagged once
+	offset, desynced, changed := pm.CheckDesync("bob", 3, 15)
+	assert.InDelta(t, -4, offset, 1e-9)
+	assert.True(t, desynced)
+	assert.True(t, changed)
+	_, _, changed = pm.CheckDesync("bob", 3, 16)
+	assert.False(t, changed)
+	assert.Contains(t, pm.Offsets(16), "bob")
+
+	// Test case 2: catching up clears the flag
+	assert.NoError(t, pm.SetUserPlaystate("bob", 106, false, false, "bob", 16, false))
+	_, desynced, changed = pm.CheckDesync("bob", 3, 16)
+	assert.False(t, desynced)
+	assert.True(t, changed)
+
+	// Test case 3: a file of a different length is not judged
+	assert.NoError(t, pm.SetUserFile("bob", File{Name: "a.mkv", Duration: 300}))
+	assert.NoError(t, pm.SetUserPlaystate("bob", 90, false, false, "bob", 17, false))
+	_, desynced, _ = pm.CheckDesync("bob", 3, 17)
+	assert.False(t, desynced)
+	assert.NotContains(t, pm.Offsets(17), "bob")
+
+	// Test case 4: a controller drifting away is reported instead of moving the clock
+	assert.NoError(t, pm.SetUserPlaystate("alice", 103.8, false, false, "alice", 18, false))
+	assert.InDelta(t, 108, pm.GetClock(18).Position, 1e-9)
+	offset, desynced, changed = pm.CheckDesync("alice", 3, 18)
+	assert.InDelta(t, -4.2, offset, 1e-9)
+	assert.True(t, desynced)
+	assert.True(t, changed)
+
+	// Test case 5: the desync range decides when the report of a user alone moves the clock
+	assert.NoError(t, pm.RemoveUserPlaystate("bob"))
+	pm.SetDesyncRange(0.5)
+	assert.NoError(t, pm.SetUserPlaystate("alice", 200, false, false, "alice", 19, false))
+	assert.InDelta(t, 200, pm.GetClock(19).Position, 1e-9)
+	_, desynced, _ = pm.CheckDesync("alice", 3, 19)
+	assert.False(t, desynced)
+}
+
+func TestPresenterDrift(t *testing.T) {
+	Features.SetConfig(*Features.NewConfig())
+	pm := NewPlaylistManager()
+	for _, username := range []string{"presenter", "viewer"} {
+		assert.NoError(t, pm.CreateUserPlaystate(username))
+		assert.NoError(t, pm.SetUserFile(username, File{Name: "a.mkv", Duration: 600}))
+	}
+	pm.SetPresenter("presenter")
+
+	assert.NoError(t, pm.SetUserPlaystate("presenter", 100, false, false, "presenter", 10, true))
+
+	// Test case 1: the presenter buffering past the desync range moves the clock
+	assert.NoError(t, pm.SetUserPlaystate("presenter", 101, false, false, "presenter", 15, false))
+	assert.InDelta(t, 101, pm.GetClock(15).Position, 1e-9)
+
+	// Test case 2: the viewer is measured against the presenter's clock and does not move it
+	assert.NoError(t, pm.RecordUserPlaystate("viewer", 105, false, false, 15))
+	assert.InDelta(t, 101, pm.GetClock(15).Position, 1e-9)
+	offset, desynced, _ := pm.CheckDesync("viewer", 3, 15)
+	assert.InDelta(t, 4, offset, 1e-9)
+	assert.True(t, desynced)
+
+	// Test case 3: without presenter mode the same drift is only reported
+	pm.SetPresenter("")
+	assert.NoError(t, pm.SetUserPlaystate("presenter", 96, false, false, "presenter", 16, false))
+	assert.InDelta(t, 102, pm.GetClock(16).Position, 1e-9)
+}
+
+func TestRecordUserPlaystate(t *testing.T) {
+	Features.SetConfig(*Features.NewConfig())
+	pm := NewPlaylistManager()
+	assert.NoError(t, pm.CreateUserPlaystate("presenter"))
+	assert.NoError(t, pm.CreateUserPlaystate("viewer"))
+
+	// Test case 1: unknown users are rejected
+	assert.Error(t, pm.RecordUserPlaystate("nobody", 0, true, false, 0))
+
+	// Test case 2: a viewer far from the clock does not move it
+	assert.NoError(t, pm.SetUserPlaystate("presenter", 50, false, false, "presenter", 10, true))
+	assert.NoError(t, pm.RecordUserPlaystate("viewer", 20, false, false, 10))
+
+	assert.InDelta(t, 50, pm.GetClock(10).Position, 1e-9)
+	viewer, _ := pm.GetUserObject("viewer")
+	assert.InDelta(t, -30, viewer.Offset, 1e-9)
+	assert.Equal(t, float64(20), viewer.Position)
+}
+
+func TestBookmarks(t *testing.T) {
+	pm := NewPlaylistManager()
+
+	// Test case 1: rooms without a playlist keep their bookmarks too
+	assert.NoError(t, pm.AddBookmark("start", 5, "alice"))
+	assert.Len(t, pm.GetBookmarks(), 1)
+
+	// Test case 2: bookmarks belong to the selected entry
+	pm.SetFiles([]string{"a.mkv", "b.mkv"}, "alice")
+	assert.NoError(t, pm.SetIndex(0, "alice"))
+	assert.Empty(t, pm.GetBookmarks())
+	assert.NoError(t, pm.AddBookmark("fight", 3733, "alice"))
+	assert.NoError(t, pm.AddBookmark("intro", 90, "bob"))
+
+	assert.NoError(t, pm.SetIndex(1, "alice"))
+	_, ok := pm.FindBookmark("fight")
+	assert.False(t, ok)
+
+	// Test case 3: they are ordered by position and found regardless of case
+	assert.NoError(t, pm.SetIndex(0, "alice"))
+	bookmarks := pm.GetBookmarks()
+	assert.Equal(t, []string{"intro", "fight"}, []string{bookmarks[0].Name, bookmarks[1].Name})
+	bookmark, ok := pm.FindBookmark("FIGHT")
+	assert.True(t, ok)
+	assert.Equal(t, float64(3733), bookmark.Position)
+
+	// Test case 4: adding a name again moves the bookmark
+	assert.NoError(t, pm.AddBookmark("fight", 3700, "bob"))
+	bookmark, _ = pm.FindBookmark("fight")
+	assert.Equal(t, float64(3700), bookmark.Position)
+	assert.Equal(t, "bob", bookmark.SetBy)
+	assert.Len(t, pm.GetBookmarks(), 2)
+
+	// Test case 5: invalid bookmarks are rejected
+	assert.Error(t, pm.AddBookmark("", 1, "alice"))
+	assert.Error(t, pm.AddBookmark("two words", 1, "alice"))
+	assert.Error(t, pm.AddBookmark("before", -1, "alice"))
+
+	// Test case 6: removing
+	removed, err := pm.RemoveBookmark("intro")
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", removed.SetBy)
+	_, err = pm.RemoveBookmark("intro")
+	assert.Error(t, err)
+	assert.Len(t, pm.GetBookmarks(), 1)
+}
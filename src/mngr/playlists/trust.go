@@ -0,0 +1,101 @@
+package playlists
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// TrustRules decide which URLs may be added to a playlist. Entries that are not URLs
+// (plain file names) are always accepted.
+type TrustRules struct {
+	// Domains lists trusted domains, each also trusting its subdomains. Empty trusts every domain.
+	Domains []string
+	// Schemes lists the accepted URL schemes. Empty accepts every scheme.
+	Schemes []string
+}
+
+// Check returns an error explaining why an entry is not accepted
+func (r TrustRules) Check(entry string) error {
+	hierarchical := strings.Contains(entry, "://")
+
+	u, err := url.Parse(entry)
+	if err != nil {
+		if hierarchical {
+			return fmt.Errorf("%s is not a valid URL", entry)
+		}
+		// file names like "100% done.mkv" are not valid URLs
+		return nil
+	}
+
+	if !hasScheme(u, entry) {
+		return nil
+	}
+
+	if hierarchical && u.Host == "" {
+		return fmt.Errorf("%s is not a valid URL", entry)
+	}
+
+	if len(r.Schemes) > 0 && !containsFold(r.Schemes, u.Scheme) {
+		return fmt.Errorf("%s: scheme %s is not allowed", entry, u.Scheme)
+	}
+
+	if len(r.Domains) > 0 && !r.trustedHost(u.Hostname()) {
+		if u.Hostname() == "" {
+			return fmt.Errorf("%s has no trusted domain", entry)
+		}
+		return fmt.Errorf("%s: %s is not a trusted domain", entry, u.Hostname())
+	}
+
+	return nil
+}
+
+// knownSchemes are the URL schemes recognised without "://", anything else before a colon is
+// taken to be part of a file name
+var knownSchemes = []string{
+	"http", "https", "ftp", "ftps", "sftp", "file", "magnet", "javascript", "data",
+	"rtmp", "rtmps", "rtsp", "rtp", "udp", "smb", "mailto", "ws", "wss",
+}
+
+// hasScheme reports whether a parsed entry is a URL rather than a file name. Drive letters
+// ("C:\video.mkv") and names like "Episode:1.mkv" also parse with a scheme.
+func hasScheme(u *url.URL, entry string) bool {
+	if u.Scheme == "" {
+		return false
+	}
+	return strings.Contains(entry, "://") || containsFold(knownSchemes, u.Scheme)
+}
+
+// Filter splits entries into accepted ones and the reasons the others were rejected
+func (r TrustRules) Filter(entries []string) ([]string, []error) {
+	accepted := make([]string, 0, len(entries))
+	rejected := make([]error, 0)
+	for _, entry := range entries {
+		if err := r.Check(entry); err != nil {
+			rejected = append(rejected, err)
+			continue
+		}
+		accepted = append(accepted, entry)
+	}
+	return accepted, rejected
+}
+
+func (r TrustRules) trustedHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, domain := range r.Domains {
+		domain = strings.ToLower(strings.TrimPrefix(domain, "*."))
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
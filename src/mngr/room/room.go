@@ -6,7 +6,9 @@ import (
 	"runtime"
 	"sync"
 
+	Features "github.com/Icey-Glitch/Syncplay-G/features"
 	"github.com/Icey-Glitch/Syncplay-G/mngr/event"
+	"github.com/Icey-Glitch/Syncplay-G/mngr/latency"
 	playlistsM "github.com/Icey-Glitch/Syncplay-G/mngr/playlists"
 	"github.com/Icey-Glitch/Syncplay-G/mngr/ready"
 )
@@ -26,8 +28,23 @@ type Connection struct {
 	// client latency calculation struct
 	ClientLatencyCalculation *ClientLatencyCalculation
 
+	// round-trip time measurement of this connection
+	Latency *latency.Tracker
+
+	// ignoringOnTheFly counters of this connection
+	OnTheFly *OnTheFly
+
 	StateEvent *event.ManagedEvent
 	Owner      *Room
+
+	// users this connection does not accept direct messages from
+	BlockList *BlockList
+
+	// the client declared support for the playback speed extension
+	SupportsSpeed bool
+
+	// the client asked to join as a spectator, which also holds in rooms it moves to
+	RequestedSpectator bool
 }
 
 type ClientLatencyCalculation struct {
@@ -46,6 +63,86 @@ type Room struct {
 
 	stateEventManager *event.EventManager
 	stateEventTicker  *event.Ticker
+
+	// published when the last connection leaves
+	emptyEvent *event.Event
+
+	muted      map[string]bool
+	operators  map[string]bool
+	spectators map[string]bool
+	settings   Settings
+
+	// the user whose playback everyone follows, empty when presenter mode is off
+	presenter string
+}
+
+// Settings holds the policies of a single room
+type Settings struct {
+	AutoPlay          bool
+	AutoPlayCountdown int // seconds
+	StrictReadiness   bool
+
+	// readiness is reset when the room moves on
+	ResetReadyOnIndexChange bool
+	ResetReadyOnFileChange  bool
+	ResetReadyOnSeek        bool
+	SeekResetThreshold      float64 // seconds
+
+	// playlist behaviour at the end of a file
+	AutoAdvance bool
+	Repeat      playlistsM.RepeatMode
+	Shuffle     bool
+
+	// URLs accepted in playlist changes
+	TrustRules playlistsM.TrustRules
+
+	// how far (seconds) a lone user's report may be off before it moves the room clock, and how far
+	// anyone may drift before the room is told
+	DesyncRange  float64
+	DesyncNotice float64
+
+	// when the server pauses the room on its own
+	PauseOnLeave bool
+	PauseOnJoin  bool
+	PauseOnLag   float64 // seconds behind, 0 when off
+
+	// new users join as spectators, and whether spectators may chat
+	JoinAsSpectator bool
+	SpectatorChat   bool
+}
+
+// DefaultSettings returns the room settings taken from the server config
+func DefaultSettings() Settings {
+	config := Features.GetConfig()
+	return Settings{
+		AutoPlay:          config.AutoPlay,
+		AutoPlayCountdown: config.AutoPlayCountdown,
+		StrictReadiness:   config.StrictReadiness,
+
+		ResetReadyOnIndexChange: config.ResetReadyOnIndexChange,
+		ResetReadyOnFileChange:  config.ResetReadyOnFileChange,
+		ResetReadyOnSeek:        config.ResetReadyOnSeek,
+		SeekResetThreshold:      config.SeekResetThreshold,
+
+		AutoAdvance: config.AutoAdvance,
+		Repeat:      playlistsM.RepeatMode(config.Repeat),
+		Shuffle:     config.Shuffle,
+
+		TrustRules: playlistsM.TrustRules{
+			Domains: append([]string(nil), config.TrustedDomains...),
+			Schemes: append([]string(nil), config.AllowedSchemes...),
+		},
+
+		DesyncRange:  config.DesyncRange,
+		DesyncNotice: config.DesyncNotice,
+
+		PauseOnLeave: config.PauseOnLeave,
+		PauseOnJoin:  config.PauseOnJoin,
+		PauseOnLag:   config.PauseOnLag,
+
+		JoinAsSpectator: config.JoinAsSpectator,
+		SpectatorChat:   config.SpectatorChat,
+	}
 }
 
 func NewRoom(name string) *Room {
@@ -56,9 +153,128 @@ func NewRoom(name string) *Room {
 		PlaylistManager:   playlistsM.NewPlaylistManager(),
 		stateEventManager: event.NewEventManager(),
 		stateEventTicker:  event.NewTicker(1, true),
+		emptyEvent:        event.NewEvent(),
+		muted:             make(map[string]bool),
+		operators:         make(map[string]bool),
+		spectators:        make(map[string]bool),
+		settings:          DefaultSettings(),
+	}
+}
+
+// BlockList holds the usernames a user does not want direct messages from
+type BlockList struct {
+	users map[string]bool
+	mutex sync.RWMutex
+}
+
+func NewBlockList() *BlockList {
+	return &BlockList{
+		users: make(map[string]bool),
+	}
+}
+
+// Block adds a username to the block list
+func (b *BlockList) Block(username string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.users[username] = true
+}
+
+// Unblock removes a username from the block list
+func (b *BlockList) Unblock(username string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	delete(b.users, username)
+}
+
+// IsBlocked reports whether a username is blocked, a nil block list blocks nobody
+func (b *BlockList) IsBlocked(username string) bool {
+	if b == nil {
+		return false
+	}
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return b.users[username]
+}
+
+// List returns the blocked usernames
+func (b *BlockList) List() []string {
+	if b == nil {
+		return nil
+	}
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	usernames := make([]string, 0, len(b.users))
+	for username := range b.users {
+		usernames = append(usernames, username)
+	}
+	return usernames
+}
+
+// OnTheFly holds the ignoringOnTheFly counters of a connection. While the server counter is set,
+// the server ignores the client's playstate until the client acknowledges the forced state.
+type OnTheFly struct {
+	server float64
+	client float64
+	mutex  sync.Mutex
+}
+
+func NewOnTheFly() *OnTheFly {
+	return &OnTheFly{}
+}
+
+// Receive processes the counters a client sent, zero meaning the counter was not sent
+func (o *OnTheFly) Receive(server float64, client float64) {
+	if o == nil {
+		return
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if server != 0 && server == o.server {
+		o.server = 0
+	}
+	if client != 0 {
+		o.client = client
 	}
 }
 
+// Ignoring reports whether the server is waiting for the client to acknowledge a forced state
+func (o *OnTheFly) Ignoring() bool {
+	if o == nil {
+		return false
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return o.server != 0
+}
+
+// Next returns the counters for the next state sent to the client. Forced states increment the
+// server counter, the client counter is echoed once.
+func (o *OnTheFly) Next(forced bool) (server float64, client float64) {
+	if o == nil {
+		return 0, 0
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if forced {
+		o.server++
+	}
+	client, o.client = o.client, 0
+	return o.server, client
+}
+
 func GetRoomByConnection(conn net.Conn, rooms map[string]*Room) *Room {
 	for _, room := range rooms {
 		for _, connection := range room.Users {
@@ -132,6 +348,12 @@ func (r *Room) AddConnection(connection *Connection) error {
 	}
 
 	r.Users = append(r.Users, connection)
+
+	// the first user in a room without operators runs it
+	if len(r.operators) == 0 {
+		r.operators[connection.Username] = true
+	}
+
 	if err := r.PlaylistManager.CreateUserPlaystate(connection.Username); err != nil {
 		fmt.Println("Failed to create user playstate " + err.Error())
 		return err
@@ -176,6 +398,17 @@ func (r *Room) RemoveConnection(conn net.Conn) {
 		}
 
 		if connection != nil {
+			if r.presenter == connection.Username {
+				r.presenter = ""
+				r.PlaylistManager.SetPresenter("")
+			}
+
+			delete(r.spectators, connection.Username)
+			delete(r.operators, connection.Username)
+			if len(r.operators) == 0 && len(r.Users) > 0 {
+				r.operators[r.Users[0].Username] = true
+			}
+
 			r.removeUserStates(connection)
 			if connection.StateEvent != nil {
 				connection.StateEvent.Stop()
@@ -185,6 +418,7 @@ func (r *Room) RemoveConnection(conn net.Conn) {
 		// Tear down the room if there are no more connections
 		if len(r.Users) == 0 {
 			r.stateEventManager.StopAll()
+			r.emptyEvent.Publish(r)
 			// run GC
 			runtime.GC()
 		}
@@ -199,6 +433,16 @@ func (r *Room) removeUserStates(connection *Connection) {
 	}
 }
 
+// SubscribeToEmpty returns a channel that receives the room whenever its last connection leaves
+func (r *Room) SubscribeToEmpty() chan interface{} {
+	return r.emptyEvent.Subscribe()
+}
+
+// UnsubscribeFromEmpty stops and closes a channel returned by SubscribeToEmpty
+func (r *Room) UnsubscribeFromEmpty(ch chan interface{}) {
+	r.emptyEvent.Unsubscribe(ch)
+}
+
 // GetStateEventManager returns the state event manager
 func (r *Room) GetStateEventManager() *event.EventManager {
 	return r.stateEventManager
@@ -226,6 +470,139 @@ func (r *Room) SetUserReadyState(username string, isReady bool, manuallyInitiate
 	r.ReadyManager.SetUserReadyState(username, isReady, manuallyInitiated)
 }
 
+// MuteUser stops a user from sending chat or direct messages in the room
+func (r *Room) MuteUser(username string) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	r.muted[username] = true
+}
+
+// UnmuteUser allows a muted user to chat again
+func (r *Room) UnmuteUser(username string) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	delete(r.muted, username)
+}
+
+// IsMuted reports whether a user is muted in the room
+func (r *Room) IsMuted(username string) bool {
+	r.Mutex.RLock()
+	defer r.Mutex.RUnlock()
+
+	return r.muted[username]
+}
+
+// AddOperator lets a user control other users in the room
+func (r *Room) AddOperator(username string) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	r.operators[username] = true
+}
+
+// RemoveOperator revokes a user's operator rights
+func (r *Room) RemoveOperator(username string) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	delete(r.operators, username)
+}
+
+// IsOperator reports whether a user is an operator of the room
+func (r *Room) IsOperator(username string) bool {
+	r.Mutex.RLock()
+	defer r.Mutex.RUnlock()
+
+	return r.operators[username]
+}
+
+// AddSpectator makes a user a spectator, who follows playback without controlling it
+func (r *Room) AddSpectator(username string) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	r.spectators[username] = true
+}
+
+// RemoveSpectator makes a spectator a regular user again
+func (r *Room) RemoveSpectator(username string) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	delete(r.spectators, username)
+}
+
+// IsSpectator reports whether a user is a spectator in the room
+func (r *Room) IsSpectator(username string) bool {
+	r.Mutex.RLock()
+	defer r.Mutex.RUnlock()
+
+	return r.spectators[username]
+}
+
+// SetPresenter makes a user the presenter of the room, an empty username ends presenter mode
+func (r *Room) SetPresenter(username string) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	r.presenter = username
+	r.PlaylistManager.SetPresenter(username)
+}
+
+// GetPresenter returns the presenter of the room, empty when presenter mode is off
+func (r *Room) GetPresenter() string {
+	r.Mutex.RLock()
+	defer r.Mutex.RUnlock()
+
+	return r.presenter
+}
+
+// GetSettings returns the room settings
+func (r *Room) GetSettings() Settings {
+	r.Mutex.RLock()
+	defer r.Mutex.RUnlock()
+
+	return r.settings
+}
+
+// SetSettings replaces the room settings
+func (r *Room) SetSettings(settings Settings) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	r.settings = settings
+	r.PlaylistManager.SetDesyncRange(settings.DesyncRange)
+}
+
+// UsersNotReady returns the users in the room that are not ready, spectators are not counted
+func (r *Room) UsersNotReady() []string {
+	r.Mutex.RLock()
+	defer r.Mutex.RUnlock()
+
+	notReady := make([]string, 0)
+	for _, connection := range r.Users {
+		if r.spectators[connection.Username] {
+			continue
+		}
+		state, exists := r.ReadyManager.GetUserReadyState(connection.Username)
+		if !exists || !state.IsReady {
+			notReady = append(notReady, connection.Username)
+		}
+	}
+	return notReady
+}
+
+// AllUsersReady reports whether the room has users other than spectators and all of them are ready
+func (r *Room) AllUsersReady() bool {
+	r.Mutex.RLock()
+	participants := len(r.Users) - len(r.spectators)
+	r.Mutex.RUnlock()
+
+	return participants > 0 && len(r.UsersNotReady()) == 0
+}
+
 // PrintReadyStates print all ready states
 func (r *Room) PrintReadyStates() {
 	r.Mutex.RLock()
@@ -349,6 +349,101 @@ func TestGetStateEventTicker(t *testing.T) {
 	assert.NotNil(t, ticker)
 }
 
+func TestMuteUser(t *testing.T) {
+	room := NewRoom("testRoom")
+
+	assert.False(t, room.IsMuted("testUser"))
+
+	room.MuteUser("testUser")
+	assert.True(t, room.IsMuted("testUser"))
+
+	room.UnmuteUser("testUser")
+	assert.False(t, room.IsMuted("testUser"))
+}
+
+func TestOperators(t *testing.T) {
+	room := NewRoom("testRoom")
+	conn1 := &Connection{
+		Username: "testUser1",
+		Conn:     &net.TCPConn{},
+		Owner:    room,
+	}
+	conn2 := &Connection{
+		Username: "testUser2",
+		Conn:     &net.TCPConn{},
+		Owner:    room,
+	}
+
+	assert.NoError(t, room.AddConnection(conn1))
+	assert.NoError(t, room.AddConnection(conn2))
+
+	// Test case 1: the first user becomes operator
+	assert.True(t, room.IsOperator("testUser1"))
+	assert.False(t, room.IsOperator("testUser2"))
+
+	// Test case 2: operators can be added and removed
+	room.AddOperator("testUser2")
+	assert.True(t, room.IsOperator("testUser2"))
+	room.RemoveOperator("testUser2")
+	assert.False(t, room.IsOperator("testUser2"))
+
+	// Test case 3: the last operator leaving hands over to a remaining user
+	room.RemoveConnection(conn1.Conn)
+	assert.False(t, room.IsOperator("testUser1"))
+	assert.True(t, room.IsOperator("testUser2"))
+}
+
+func TestAllUsersReady(t *testing.T) {
+	room := NewRoom("testRoom")
+
+	// Test case 1: an empty room is never ready
+	assert.False(t, room.AllUsersReady())
+
+	conn1 := &Connection{Username: "testUser1", Conn: &net.TCPConn{}, Owner: room}
+	conn2 := &Connection{Username: "testUser2", Conn: &net.TCPConn{}, Owner: room}
+	assert.NoError(t, room.AddConnection(conn1))
+	assert.NoError(t, room.AddConnection(conn2))
+
+	// Test case 2: users without a ready state are not ready
+	room.SetUserReadyState("testUser1", true, true)
+	assert.False(t, room.AllUsersReady())
+	assert.Equal(t, []string{"testUser2"}, room.UsersNotReady())
+
+	// Test case 3: everyone ready
+	room.SetUserReadyState("testUser2", true, true)
+	assert.True(t, room.AllUsersReady())
+	assert.Empty(t, room.UsersNotReady())
+}
+
+func TestSettings(t *testing.T) {
+	room := NewRoom("testRoom")
+
+	settings := room.GetSettings()
+	settings.AutoPlay = true
+	settings.AutoPlayCountdown = 3
+	room.SetSettings(settings)
+
+	assert.True(t, room.GetSettings().AutoPlay)
+	assert.Equal(t, 3, room.GetSettings().AutoPlayCountdown)
+}
+
+func TestBlockList(t *testing.T) {
+	blockList := NewBlockList()
+
+	blockList.Block("spammer")
+	assert.True(t, blockList.IsBlocked("spammer"))
+	assert.False(t, blockList.IsBlocked("friend"))
+	assert.Equal(t, []string{"spammer"}, blockList.List())
+
+	blockList.Unblock("spammer")
+	assert.False(t, blockList.IsBlocked("spammer"))
+
+	// Test case: nil block list blocks nobody
+	var nilList *BlockList
+	assert.False(t, nilList.IsBlocked("spammer"))
+	assert.Empty(t, nilList.List())
+}
+
 // Helper function to capture output
 func captureOutput(f func()) string {
 	old := os.Stdout
@@ -364,3 +459,81 @@ func captureOutput(f func()) string {
 	io.Copy(&buf, r)
 	return buf.String()
 }
+
+func TestOnTheFly(t *testing.T) {
+	onTheFly := NewOnTheFly()
+
+	// Test case 1: keepalive states carry no counters
+	server, client := onTheFly.Next(false)
+	assert.Equal(t, 0.0, server)
+	assert.Equal(t, 0.0, client)
+	assert.False(t, onTheFly.Ignoring())
+
+	// Test case 2: forced states are ignored on the client's side until acknowledged
+	server, _ = onTheFly.Next(true)
+	assert.Equal(t, 1.0, server)
+	assert.True(t, onTheFly.Ignoring())
+
+	onTheFly.Receive(2, 0)
+	assert.True(t, onTheFly.Ignoring())
+	onTheFly.Receive(1, 0)
+	assert.False(t, onTheFly.Ignoring())
+
+	// Test case 3: the client counter is echoed exactly once
+	onTheFly.Receive(0, 3)
+	_, client = onTheFly.Next(false)
+	assert.Equal(t, 3.0, client)
+	_, client = onTheFly.Next(false)
+	assert.Equal(t, 0.0, client)
+
+	// Test case 4: a nil counter never ignores
+	var none *OnTheFly
+	none.Receive(1, 1)
+	assert.False(t, none.Ignoring())
+}
+
+func TestPresenter(t *testing.T) {
+	room := NewRoom("testRoom")
+	conn1 := &Connection{Username: "testUser1", Conn: &net.TCPConn{}, Owner: room}
+	conn2 := &Connection{Username: "testUser2", Conn: &net.TCPConn{}, Owner: room}
+	assert.NoError(t, room.AddConnection(conn1))
+	assert.NoError(t, room.AddConnection(conn2))
+
+	// Test case 1: presenter mode is off by default
+	assert.Equal(t, "", room.GetPresenter())
+
+	// Test case 2: other users leaving keep the presenter
+	room.SetPresenter("testUser1")
+	room.RemoveConnection(conn2.Conn)
+	assert.Equal(t, "testUser1", room.GetPresenter())
+
+	// Test case 3: the presenter leaving ends presenter mode
+	room.RemoveConnection(conn1.Conn)
+	assert.Equal(t, "", room.GetPresenter())
+}
+
+func TestSpectators(t *testing.T) {
+	room := NewRoom("testRoom")
+	conn1 := &Connection{Username: "testUser1", Conn: &net.TCPConn{}, Owner: room}
+	conn2 := &Connection{Username: "testUser2", Conn: &net.TCPConn{}, Owner: room}
+	assert.NoError(t, room.AddConnection(conn1))
+	assert.NoError(t, room.AddConnection(conn2))
+
+	// Test case 1: spectators do not count toward readiness
+	room.AddSpectator("testUser2")
+	assert.True(t, room.IsSpectator("testUser2"))
+	assert.Equal(t, []string{"testUser1"}, room.UsersNotReady())
+
+	room.SetUserReadyState("testUser1", true, true)
+	assert.True(t, room.AllUsersReady())
+
+	// Test case 2: a room of spectators only is never ready
+	room.AddSpectator("testUser1")
+	assert.False(t, room.AllUsersReady())
+
+	// Test case 3: spectators can become regular users again, and leaving clears the role
+	room.RemoveSpectator("testUser1")
+	assert.False(t, room.IsSpectator("testUser1"))
+	room.RemoveConnection(conn2.Conn)
+	assert.False(t, room.IsSpectator("testUser2"))
+}